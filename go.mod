@@ -8,6 +8,7 @@ require (
 	github.com/TwiN/go-color v1.4.1
 	github.com/elsudano/govmx v1.0.0
 	github.com/rs/zerolog v1.34.0
+	golang.org/x/crypto v0.38.0
 )
 
 require (
@@ -0,0 +1,305 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrCassetteMismatch the replay mode has received an API call that the cassette doesn't have.
+var ErrCassetteMismatch = errors.New("the API call isn't in the cassette")
+
+// CassetteMatch says which fields of the API call we compare in the replay mode,
+// we can combine them like MatchMethod | MatchPath.
+type CassetteMatch int
+
+const (
+	// MatchMethod compare the HTTP method.
+	MatchMethod CassetteMatch = 1 << iota
+	// MatchPath compare the path of the API, with the query.
+	MatchPath
+	// MatchBody compare the body of the request, the JSON bodies are compared by value.
+	MatchBody
+	// MatchAll compare the method, the path and the body, it's the default.
+	MatchAll = MatchMethod | MatchPath | MatchBody
+)
+
+// headersNotRecorded are the headers that never go to the cassette, because they have credentials.
+var headersNotRecorded = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Cassette is a list of API calls with their responses that we have recorded
+// from a real vmrest server, in order to replay them later in our tests.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one API call of the Cassette.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is the request of an Interaction, the Path is relative to the
+// BaseURL of the client, so we can replay it with any address.
+type CassetteRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+// CassetteResponse is the response of an Interaction.
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// LoadCassette function read a cassette from the file f.
+// Inputs:
+// f: (string) The path of the cassette.
+// Outputs:
+// (*Cassette) The cassette.
+// error: (error) If we can't read the file or it isn't a cassette.
+func LoadCassette(f string) (*Cassette, error) {
+	content, err := os.ReadFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading the cassette: %w", err)
+	}
+	cassette := new(Cassette)
+	err = json.Unmarshal(content, cassette)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the cassette %s: %w", f, err)
+	}
+	return cassette, nil
+}
+
+// Save method write the cassette in the file f, we use a temporary file so
+// the cassette is never half written.
+// Inputs:
+// f: (string) The path of the cassette.
+// Outputs:
+// error: (error) If we can't write the file.
+func (cs *Cassette) Save(f string) error {
+	content, err := json.MarshalIndent(cs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding the cassette: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f), filepath.Base(f)+".*")
+	if err != nil {
+		return fmt.Errorf("writing the cassette: %w", err)
+	}
+	_, err = tmp.Write(append(content, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing the cassette: %w", err)
+	}
+	return nil
+}
+
+// WithCassetteRecording option record all the API calls of the client in the
+// cassette f, the credentials are never recorded and the bodies are redacted
+// with the Redactor of the client. The file is written after each API call.
+// Inputs:
+// f: (string) The path of the cassette, it's overwritten.
+func WithCassetteRecording(f string) Option {
+	return func(c *HTTPClient) error {
+		recorder := &cassetteRecorder{client: c, file: f, cassette: new(Cassette)}
+		c.middlewares = append(c.middlewares, func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return recorder.roundTrip(next, req)
+			})
+		})
+		return nil
+	}
+}
+
+// WithCassetteReplay option serve all the API calls of the client from the cassette f,
+// without talk with any server. Each Interaction is used just once and in order, if
+// an API call doesn't match with any Interaction that we haven't used the call fails
+// with ErrCassetteMismatch.
+// Inputs:
+// f: (string) The path of the cassette.
+// match: (CassetteMatch) The fields that we compare, 0 means MatchAll.
+func WithCassetteReplay(f string, match CassetteMatch) Option {
+	return func(c *HTTPClient) error {
+		cassette, err := LoadCassette(f)
+		if err != nil {
+			return err
+		}
+		if match == 0 {
+			match = MatchAll
+		}
+		player := &cassettePlayer{
+			client:   c,
+			cassette: cassette,
+			match:    match,
+			used:     make([]bool, len(cassette.Interactions)),
+		}
+		c.middlewares = append(c.middlewares, func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(player.roundTrip)
+		})
+		return nil
+	}
+}
+
+// cassetteRecorder is the middleware of the recording mode.
+type cassetteRecorder struct {
+	client   *HTTPClient
+	file     string
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// roundTrip method make the API call with next and record it.
+func (cr *cassetteRecorder) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	request, err := cr.client.cassetteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	response, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	header := cr.client.redactor().Header(response.Header)
+	for _, h := range headersNotRecorded {
+		header.Del(h)
+	}
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.cassette.Interactions = append(cr.cassette.Interactions, Interaction{
+		Request: request,
+		Response: CassetteResponse{
+			StatusCode: response.StatusCode,
+			Header:     header,
+			Body:       cr.client.scrub(string(body)),
+		},
+	})
+	err = cr.cassette.Save(cr.file)
+	if err != nil {
+		return nil, err
+	}
+	cr.client.Log().Debug().Msgf("We have recorded the API call %s %s", request.Method, request.Path)
+	return response, nil
+}
+
+// cassettePlayer is the middleware of the replay mode.
+type cassettePlayer struct {
+	client   *HTTPClient
+	mu       sync.Mutex
+	cassette *Cassette
+	match    CassetteMatch
+	used     []bool
+}
+
+// roundTrip method answer the API call with the first Interaction that match and we haven't used.
+func (cp *cassettePlayer) roundTrip(req *http.Request) (*http.Response, error) {
+	request, err := cp.client.cassetteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for pos, interaction := range cp.cassette.Interactions {
+		if cp.used[pos] || !cp.matches(interaction.Request, request) {
+			continue
+		}
+		cp.used[pos] = true
+		cp.client.Log().Debug().Msgf("We have replayed the API call %s %s", request.Method, request.Path)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s with body %q", ErrCassetteMismatch, request.Method, request.Path, request.Body)
+}
+
+// matches method compare the recorded request with the request of the API call.
+func (cp *cassettePlayer) matches(recorded CassetteRequest, request CassetteRequest) bool {
+	if cp.match&MatchMethod != 0 && recorded.Method != request.Method {
+		return false
+	}
+	if cp.match&MatchPath != 0 && recorded.Path != request.Path {
+		return false
+	}
+	if cp.match&MatchBody != 0 && !sameBody(recorded.Body, request.Body) {
+		return false
+	}
+	return true
+}
+
+// cassetteRequest method return the request as we keep it in the cassette, with
+// the path relative to the BaseURL and the body redacted.
+func (c *HTTPClient) cassetteRequest(req *http.Request) (CassetteRequest, error) {
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return CassetteRequest{}, err
+		}
+		body, err = io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return CassetteRequest{}, err
+		}
+	}
+	p := req.URL.Path
+	if c.BaseURL != nil {
+		p = strings.TrimPrefix(p, strings.TrimSuffix(c.BaseURL.Path, "/"))
+	}
+	p = strings.TrimLeft(p, "/")
+	if req.URL.RawQuery != "" {
+		p += "?" + req.URL.RawQuery
+	}
+	return CassetteRequest{Method: req.Method, Path: p, Body: c.scrub(string(body))}, nil
+}
+
+// scrub method redact the body that we keep in the cassette, if there isn't any
+// secret we keep the original body, because the Redactor can change the format of the JSON.
+func (c *HTTPClient) scrub(body string) string {
+	redacted := c.Redact(body)
+	if sameBody(redacted, body) {
+		return body
+	}
+	return redacted
+}
+
+// sameBody Auxiliary function to compare two bodies, if both are JSON we compare
+// the values, in this way the order of the keys or the spaces don't matter.
+func sameBody(a string, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	const secret = "guest-S3cret-value"
+	f := filepath.Join(t.TempDir(), "session.json")
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID", Processors: 2, Memory: 1024})
+	recorder, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithCassetteRecording(f))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	calls := []struct {
+		path   string
+		method string
+		body   string
+	}{
+		{"vms/VMID", "GET", ""},
+		{"vms/VMID/configparams", "PUT", `{"name":"guestinfo.password","value":"` + secret + `"}`},
+		{"vms/VMID/power", "GET", ""},
+		{"vms/VMID/power", "PUT", "on"},
+		{"vms/VMID/power", "GET", ""},
+	}
+	var recorded []string
+	for _, call := range calls {
+		body, err := recorder.ApiCall(call.path, call.method, *bytes.NewBufferString(call.body))
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		content, _ := io.ReadAll(body)
+		body.Close()
+		recorded = append(recorded, string(content))
+	}
+	server.Close()
+	content, err := os.ReadFile(f)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	for _, leak := range []string{server.Password, secret, "Basic "} {
+		if strings.Contains(string(content), leak) {
+			t.Errorf("The cassette contains %q:\n%s", leak, content)
+		}
+	}
+	player, err := NewClient("https://vmrest.invalid:8697/api", "user", "password", false, "NONE", WithCassetteReplay(f, MatchMethod|MatchPath))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	for pos, call := range calls {
+		body, err := player.ApiCall(call.path, call.method, *bytes.NewBufferString(call.body))
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		content, _ := io.ReadAll(body)
+		body.Close()
+		if string(content) != recorded[pos] {
+			t.Errorf("The replay of %s %s is %q, we want %q", call.method, call.path, content, recorded[pos])
+		}
+	}
+	_, err = player.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	if !errors.Is(err, ErrCassetteMismatch) {
+		t.Errorf("The cassette is used, the error should be ErrCassetteMismatch: %#v", err)
+	}
+}
+
+func TestCassetteReplayMatchBody(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "session.json")
+	cassette := &Cassette{Interactions: []Interaction{{
+		Request:  CassetteRequest{Method: "PUT", Path: "vms/VMID", Body: `{"processors":2,"memory":1024}`},
+		Response: CassetteResponse{StatusCode: 409, Body: `{"code":106,"message":"The operation is not allowed"}`},
+	}}}
+	err := cassette.Save(f)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	player, err := NewClient("https://vmrest.invalid:8697/api", "user", "password", false, "NONE", WithCassetteReplay(f, 0))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, err = player.ApiCall("vms/VMID", "PUT", *bytes.NewBufferString(`{"processors":4,"memory":1024}`))
+	if !errors.Is(err, ErrCassetteMismatch) || errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("A different body should be ErrCassetteMismatch: %#v", err)
+	}
+	_, err = player.ApiCall("vms/VMID", "PUT", *bytes.NewBufferString(`{ "memory": 1024, "processors": 2 }`))
+	var apierr *APIError
+	if !errors.As(err, &apierr) || apierr.Code != 106 {
+		t.Errorf("The replay should give us the recorded error: %#v", err)
+	}
+	_, err = NewClient("https://vmrest.invalid:8697/api", "user", "password", false, "NONE", WithCassetteReplay(f+".missing", 0))
+	if err == nil {
+		t.Errorf("A missing cassette should be an error")
+	}
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"context"
+	"sync"
+)
+
+// WithRequestCoalescing option make that the GET calls with the same path that we do
+// at the same time share one API call and one response body, it's useful when a lot
+// of goroutines read the same VMs. When we do a PUT, POST or DELETE the GET calls that
+// start after it has finished don't share the response of the ones that started before
+// or during it, so we never read old data after a change made with this client.
+func WithRequestCoalescing() Option {
+	return func(c *HTTPClient) error {
+		c.flights = &flightGroup{}
+		return nil
+	}
+}
+
+// flight is an API call that is in progress, the callers that arrive while it's
+// in progress wait for its result instead of doing the same call.
+// done: (chan struct{}) It's closed when the call has finished.
+// body: ([]byte) The response body, the same for all the callers.
+// err: (error) The error of the call.
+// waiters: (int) The number of callers that are waiting for the call.
+// cancel: (context.CancelFunc) Cancel the call, we use it when all the callers have left.
+type flight struct {
+	done    chan struct{}
+	body    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// flightGroup is the set of API calls in progress, by request path.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// do method make the API call with fn or join the call in progress with the same key. The call
+// runs in its own goroutine with its own context, in this way a caller can leave when its
+// context is done without cancelling the call for the rest of them, we only cancel the
+// call when all the callers have left.
+// Inputs:
+// ctx: (context.Context) The context of this caller.
+// key: (string) The identity of the call, calls with the same key are the same call.
+// fn: (func(ctx context.Context) ([]byte, error)) The function that make the API call.
+// Outputs:
+// ([]byte) The response body.
+// (bool) True if we have joined a call that was in progress.
+// (error) The error of the call or the error of ctx.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]byte, error)) ([]byte, bool, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, joined := g.flights[key]
+	if joined {
+		f.waiters++
+	} else {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.flights[key] = f
+		go func() {
+			defer cancel()
+			f.body, f.err = fn(flightCtx)
+			g.mu.Lock()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+			g.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	g.mu.Unlock()
+	select {
+	case <-f.done:
+		return f.body, joined, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		return nil, joined, ctx.Err()
+	}
+}
+
+// forget method make that the next calls don't join the calls in progress, these ones finish as usual.
+func (g *flightGroup) forget() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.flights = nil
+}
@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestRequestCoalescing(t *testing.T) {
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	server.On("GET", "vms").Delay(50 * time.Millisecond)
+	apiClient, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithRequestCoalescing())
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 10)
+	errs := make([]error, 10)
+	for pos := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+			if err != nil {
+				errs[pos] = err
+				return
+			}
+			bodies[pos], errs[pos] = io.ReadAll(response)
+		}()
+	}
+	// This caller leave before the end of the call, the rest of them don't notice it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = apiClient.ApiCallContext(ctx, "vms", "GET", bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The caller should have its own deadline: %#v", err)
+	}
+	wg.Wait()
+	for pos, body := range bodies {
+		var vms []map[string]string
+		if errs[pos] != nil || json.Unmarshal(body, &vms) != nil || len(vms) != 1 || vms[0]["id"] != "VMID" {
+			t.Errorf("The caller %d hasn't got the response: %#v %q", pos, errs[pos], body)
+		}
+	}
+	if calls := server.CountRequests("GET", "vms"); calls > 2 {
+		t.Errorf("The GET calls should share the API call, we have made %d", calls)
+	}
+}
+
+func TestRequestCoalescingOnlyGET(t *testing.T) {
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	server.On("PUT", "vms/VMID/power").Delay(20 * time.Millisecond)
+	apiClient, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithRequestCoalescing())
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			apiClient.ApiCall("vms/VMID/power", "PUT", *bytes.NewBufferString("on"))
+		}()
+	}
+	wg.Wait()
+	if calls := server.CountRequests("PUT", "vms/VMID/power"); calls != 3 {
+		t.Errorf("We never share the calls that change the server, we have made %d", calls)
+	}
+}
+
+func TestRequestCoalescingAfterChange(t *testing.T) {
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	server.On("GET", "vms/VMID/power").Once().Delay(50 * time.Millisecond)
+	apiClient, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithRequestCoalescing())
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		apiClient.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_, err = apiClient.ApiCall("vms/VMID/power", "PUT", *bytes.NewBufferString("on"))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	response, err := apiClient.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, _ := io.ReadAll(response)
+	if !bytes.Contains(body, []byte(wsapitest.PoweredOn)) {
+		t.Errorf("After a change we don't want the old response: %s", body)
+	}
+	<-done
+	if calls := server.CountRequests("GET", "vms/VMID/power"); calls != 2 {
+		t.Errorf("The GET after the change should make its own API call, we have made %d", calls)
+	}
+}
+
+func TestRequestCoalescingDuringChange(t *testing.T) {
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	server.On("GET", "vms/VMID/power").Delay(100 * time.Millisecond)
+	server.On("PUT", "vms/VMID/power").Delay(50 * time.Millisecond)
+	apiClient, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithRequestCoalescing())
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	var wg sync.WaitGroup
+	get := func() {
+		defer wg.Done()
+		apiClient.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	}
+	wg.Add(2)
+	go get()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		// This GET starts while the PUT is in progress, it can read the old state
+		time.Sleep(20 * time.Millisecond)
+		get()
+	}()
+	_, err = apiClient.ApiCall("vms/VMID/power", "PUT", *bytes.NewBufferString("on"))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	response, err := apiClient.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, _ := io.ReadAll(response)
+	if !bytes.Contains(body, []byte(wsapitest.PoweredOn)) {
+		t.Errorf("After a change we don't want the old response: %s", body)
+	}
+	wg.Wait()
+	if calls := server.CountRequests("GET", "vms/VMID/power"); calls != 3 {
+		t.Errorf("The GET after the change shouldn't join the GET that started during it, we have made %d calls", calls)
+	}
+}
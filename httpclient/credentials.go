@@ -0,0 +1,412 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	defaultUserEnv       = "WSAPI_USER"
+	defaultPasswordEnv   = "WSAPI_PASSWORD"
+	defaultPassphraseEnv = "WSAPI_PASSPHRASE"
+	encryptedVersion     = 1
+	encryptedKDF         = "pbkdf2-sha256"
+	encryptedIterations  = 200000
+	encryptedSaltSize    = 16
+	// encryptedMaxIterations limit the iterations that we accept from a file, a corrupt
+	// or tampered file could make us derive the key almost forever.
+	encryptedMaxIterations = 100 * encryptedIterations
+)
+
+// ErrNoCredentials the provider doesn't have credentials to give us.
+var ErrNoCredentials = errors.New("no credentials")
+
+// CredentialProvider is the interface that we use to get the user and the password
+// of the vmrest server just before each API call, in this way if the password is
+// rotated the client picks up the new one without being configured again.
+type CredentialProvider interface {
+	// Credentials return the user and password for the server in baseURL.
+	Credentials(ctx context.Context, baseURL *url.URL) (user string, password string, err error)
+}
+
+// WithCredentials option set the provider of the credentials, when we have one the
+// User and Password fields of the client are ignored.
+// Inputs:
+// p: (CredentialProvider) The provider that we want to use.
+func WithCredentials(p CredentialProvider) Option {
+	return func(c *HTTPClient) error {
+		c.Credentials = p
+		return nil
+	}
+}
+
+// credentials method return the user and the password that we have to use in the request.
+func (c *HTTPClient) credentials(ctx context.Context) (string, string, error) {
+	if c.Credentials == nil {
+		return c.User, c.Password, nil
+	}
+	return c.Credentials.Credentials(ctx, c.BaseURL)
+}
+
+// StaticCredentials provider always give the same user and password.
+type StaticCredentials struct {
+	User     string
+	Password string
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (s StaticCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	return s.User, s.Password, nil
+}
+
+// EnvCredentials provider read the credentials from environment variables.
+// UserVar: (string) Variable with the user, WSAPI_USER by default.
+// PasswordVar: (string) Variable with the password, WSAPI_PASSWORD by default.
+type EnvCredentials struct {
+	UserVar     string
+	PasswordVar string
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (e EnvCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	userVar := e.UserVar
+	if userVar == "" {
+		userVar = defaultUserEnv
+	}
+	passwordVar := e.PasswordVar
+	if passwordVar == "" {
+		passwordVar = defaultPasswordEnv
+	}
+	password, ok := os.LookupEnv(passwordVar)
+	if !ok {
+		return "", "", fmt.Errorf("%w: the environment variable %s isn't defined", ErrNoCredentials, passwordVar)
+	}
+	return os.Getenv(userVar), password, nil
+}
+
+// FileCredentials provider read the credentials from a secrets file with the same
+// format that config.ini, the lines "User: name" and "Password: secret". The file
+// can't be readable by other users, otherwise we refuse to use it.
+// Path: (string) The path of the secrets file.
+type FileCredentials struct {
+	Path string
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (f FileCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	data, err := readSecretFile(f.Path)
+	if err != nil {
+		return "", "", err
+	}
+	var user, password string
+	var found bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "user":
+			user = strings.TrimSpace(value)
+		case "password":
+			password = strings.TrimSpace(value)
+			found = true
+		}
+	}
+	if !found {
+		return "", "", fmt.Errorf("%w: the file %q doesn't have a Password line", ErrNoCredentials, f.Path)
+	}
+	return user, password, nil
+}
+
+// HelperCredentials provider run an external command that follows the protocol of
+// git-credential helpers, we run "Command Args... get", we write the protocol, host
+// and path of the server in its stdin and we read the username and password lines.
+// Command: (string) The command of the helper.
+// Args: ([]string) The arguments before "get".
+type HelperCredentials struct {
+	Command string
+	Args    []string
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (h HelperCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	if h.Command == "" {
+		return "", "", fmt.Errorf("%w: the credential helper doesn't have a command", ErrNoCredentials)
+	}
+	var input bytes.Buffer
+	if baseURL != nil {
+		fmt.Fprintf(&input, "protocol=%s\nhost=%s\n", baseURL.Scheme, baseURL.Host)
+		if path := strings.Trim(baseURL.Path, "/"); path != "" {
+			fmt.Fprintf(&input, "path=%s\n", path)
+		}
+	}
+	input.WriteString("\n")
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.Command, append(append([]string{}, h.Args...), "get")...)
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", "", fmt.Errorf("running the credential helper %q: %w: %s", h.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	var user, password string
+	var found bool
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			user = value
+		case "password":
+			password = value
+			found = true
+		}
+	}
+	if !found {
+		return "", "", fmt.Errorf("%w: the credential helper %q hasn't returned a password", ErrNoCredentials, h.Command)
+	}
+	return user, password, nil
+}
+
+// EncryptedFileCredentials provider read the credentials from a local file encrypted
+// with AES-256-GCM, the key is derived from a passphrase with PBKDF2-SHA256. You can
+// create the file with the function WriteEncryptedCredentials.
+// Path: (string) The path of the encrypted file.
+// Passphrase: ([]byte) The passphrase, if it's empty we read it from PassphraseEnv.
+// PassphraseEnv: (string) Variable with the passphrase, WSAPI_PASSPHRASE by default.
+type EncryptedFileCredentials struct {
+	Path          string
+	Passphrase    []byte
+	PassphraseEnv string
+	keys          *keyCache
+}
+
+// encryptedFile is the format of the file that EncryptedFileCredentials reads.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// encryptedPayload is the content of the file once we have decrypted it.
+type encryptedPayload struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// keyCache keeps the last key that we have derived, because PBKDF2 is slow
+// on purpose and we resolve the credentials in each request.
+type keyCache struct {
+	mu     sync.Mutex
+	lookup [sha256.Size]byte
+	key    []byte
+}
+
+// NewEncryptedFileCredentials constructor of the EncryptedFileCredentials object
+// with the passphrase in the environment variable v, WSAPI_PASSPHRASE if it's empty.
+func NewEncryptedFileCredentials(path string, v string) *EncryptedFileCredentials {
+	return &EncryptedFileCredentials{Path: path, PassphraseEnv: v, keys: new(keyCache)}
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (e *EncryptedFileCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	passphrase, err := e.passphrase()
+	if err != nil {
+		return "", "", err
+	}
+	data, err := readSecretFile(e.Path)
+	if err != nil {
+		return "", "", err
+	}
+	var file encryptedFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return "", "", fmt.Errorf("the encrypted credentials %q are malformed: %w", e.Path, err)
+	}
+	if file.Version != encryptedVersion || file.KDF != encryptedKDF || file.Iterations <= 0 {
+		return "", "", fmt.Errorf("the encrypted credentials %q have an unsupported format", e.Path)
+	}
+	if file.Iterations > encryptedMaxIterations {
+		return "", "", fmt.Errorf("the encrypted credentials %q have %d iterations, the maximum is %d", e.Path, file.Iterations, encryptedMaxIterations)
+	}
+	key := e.keys.derive(passphrase, file.Salt, file.Iterations)
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", "", err
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("we can't decrypt %q, please check the passphrase: %w", e.Path, err)
+	}
+	var payload encryptedPayload
+	err = json.Unmarshal(plain, &payload)
+	if err != nil {
+		return "", "", fmt.Errorf("the encrypted credentials %q are malformed: %w", e.Path, err)
+	}
+	return payload.User, payload.Password, nil
+}
+
+// passphrase method return the passphrase from the field or the environment.
+func (e *EncryptedFileCredentials) passphrase() ([]byte, error) {
+	if len(e.Passphrase) > 0 {
+		return e.Passphrase, nil
+	}
+	passphraseEnv := e.PassphraseEnv
+	if passphraseEnv == "" {
+		passphraseEnv = defaultPassphraseEnv
+	}
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: the environment variable %s with the passphrase isn't defined", ErrNoCredentials, passphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+// WriteEncryptedCredentials function create the file f with the user u and the
+// password p encrypted with the passphrase, the file is only readable by the owner.
+// Inputs:
+// f: (string) The path of the file.
+// u: (string) The user of the vmrest server.
+// p: (string) The password of the vmrest server.
+// passphrase: ([]byte) The passphrase to derive the key.
+// Outputs:
+// error: (error) If we can't encrypt or write the file.
+func WriteEncryptedCredentials(f string, u string, p string, passphrase []byte) error {
+	if len(passphrase) == 0 {
+		return errors.New("the passphrase can't be empty")
+	}
+	plain, err := json.Marshal(encryptedPayload{User: u, Password: p})
+	if err != nil {
+		return err
+	}
+	file := encryptedFile{
+		Version:    encryptedVersion,
+		KDF:        encryptedKDF,
+		Iterations: encryptedIterations,
+		Salt:       make([]byte, encryptedSaltSize),
+	}
+	_, err = rand.Read(file.Salt)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(deriveKey(passphrase, file.Salt, file.Iterations))
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return err
+	}
+	file.Data = aead.Seal(nil, file.Nonce, plain, nil)
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f, data, 0600)
+}
+
+// CachedCredentials provider keep the credentials of other provider during TTL,
+// useful with providers that are expensive like the credential helpers.
+// Provider: (CredentialProvider) The provider that we want to cache.
+// TTL: (time.Duration) How long we keep the credentials.
+type CachedCredentials struct {
+	Provider CredentialProvider
+	TTL      time.Duration
+	mu       sync.Mutex
+	user     string
+	password string
+	expires  time.Time
+}
+
+// Credentials method to implement the CredentialProvider interface.
+func (c *CachedCredentials) Credentials(ctx context.Context, baseURL *url.URL) (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expires) {
+		return c.user, c.password, nil
+	}
+	user, password, err := c.Provider.Credentials(ctx, baseURL)
+	if err != nil {
+		return "", "", err
+	}
+	c.user, c.password, c.expires = user, password, time.Now().Add(c.TTL)
+	return user, password, nil
+}
+
+// readSecretFile function read the file f after check that other users can't read it.
+func readSecretFile(f string) ([]byte, error) {
+	info, err := os.Stat(f)
+	if err != nil {
+		return nil, fmt.Errorf("checking the secrets file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("the secrets file %q isn't a regular file", f)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("the secrets file %q has the permissions %s, it can't be accessible by group or others, run: chmod 600 %s", f, info.Mode().Perm(), f)
+	}
+	return os.ReadFile(f)
+}
+
+// derive method return the key for passphrase and salt, reusing the last one if we can.
+func (k *keyCache) derive(passphrase []byte, salt []byte, iterations int) []byte {
+	if k == nil {
+		return deriveKey(passphrase, salt, iterations)
+	}
+	h := sha256.New()
+	h.Write(passphrase)
+	h.Write(salt)
+	binary.Write(h, binary.BigEndian, int64(iterations))
+	var lookup [sha256.Size]byte
+	copy(lookup[:], h.Sum(nil))
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key != nil && k.lookup == lookup {
+		return k.key
+	}
+	k.key = deriveKey(passphrase, salt, iterations)
+	k.lookup = lookup
+	return k.key
+}
+
+// newAEAD function create the AES-GCM cipher with the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey function derive the AES-256 key from the passphrase with PBKDF2-SHA256 like RFC 8018.
+func deriveKey(passphrase []byte, salt []byte, iterations int) []byte {
+	return pbkdf2.Key(passphrase, salt, iterations, 32, sha256.New)
+}
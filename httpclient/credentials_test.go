@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("TEST_WSAPI_USER", "admin")
+	t.Setenv("TEST_WSAPI_PASSWORD", "s3cr3t")
+	user, password, err := EnvCredentials{UserVar: "TEST_WSAPI_USER", PasswordVar: "TEST_WSAPI_PASSWORD"}.Credentials(context.Background(), nil)
+	if err != nil || user != "admin" || password != "s3cr3t" {
+		t.Errorf("Credentials() = %#v, %#v, %v", user, password, err)
+	}
+	_, _, err = EnvCredentials{PasswordVar: "TEST_WSAPI_UNDEFINED"}.Credentials(context.Background(), nil)
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("The error should be ErrNoCredentials: %v", err)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The permissions check doesn't apply in windows")
+	}
+	f := filepath.Join(t.TempDir(), "secrets")
+	err := os.WriteFile(f, []byte("User: admin\nPassword: s3cr3t\n"), 0644)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, _, err = FileCredentials{Path: f}.Credentials(context.Background(), nil)
+	if err == nil {
+		t.Errorf("A secrets file readable by others should be rejected")
+	}
+	err = os.Chmod(f, 0600)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	user, password, err := FileCredentials{Path: f}.Credentials(context.Background(), nil)
+	if err != nil || user != "admin" || password != "s3cr3t" {
+		t.Errorf("Credentials() = %#v, %#v, %v", user, password, err)
+	}
+}
+
+func TestHelperCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The helper of the test is a shell script")
+	}
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper.sh")
+	script := "#!/bin/sh\n[ \"$1\" = get ] || exit 1\ncat > " + filepath.Join(dir, "input") + "\necho username=admin\necho password=s3cr3t\n"
+	err := os.WriteFile(helper, []byte(script), 0700)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	baseURL, _ := url.Parse("https://localhost:8697/api")
+	user, password, err := HelperCredentials{Command: helper}.Credentials(context.Background(), baseURL)
+	if err != nil || user != "admin" || password != "s3cr3t" {
+		t.Errorf("Credentials() = %#v, %#v, %v", user, password, err)
+	}
+	input, _ := os.ReadFile(filepath.Join(dir, "input"))
+	if string(input) != "protocol=https\nhost=localhost:8697\npath=api\n\n" {
+		t.Errorf("The helper received a wrong input: %#v", string(input))
+	}
+}
+
+func TestEncryptedFileCredentials(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "credentials.enc")
+	err := WriteEncryptedCredentials(f, "admin", "s3cr3t", []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	data, _ := os.ReadFile(f)
+	if bytes.Contains(data, []byte("s3cr3t")) {
+		t.Errorf("The password is in plain text in the file")
+	}
+	t.Setenv("TEST_WSAPI_PASSPHRASE", "passphrase")
+	provider := NewEncryptedFileCredentials(f, "TEST_WSAPI_PASSPHRASE")
+	for i := 0; i < 2; i++ {
+		user, password, err := provider.Credentials(context.Background(), nil)
+		if err != nil || user != "admin" || password != "s3cr3t" {
+			t.Errorf("Credentials() = %#v, %#v, %v", user, password, err)
+		}
+	}
+	_, _, err = (&EncryptedFileCredentials{Path: f, Passphrase: []byte("wrong")}).Credentials(context.Background(), nil)
+	if err == nil {
+		t.Errorf("The wrong passphrase should fail")
+	}
+}
+
+func TestEncryptedFileCredentialsIterations(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "credentials.enc")
+	err := WriteEncryptedCredentials(f, "admin", "s3cr3t", []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	data, _ := os.ReadFile(f)
+	var file encryptedFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	file.Iterations = encryptedMaxIterations + 1
+	data, _ = json.Marshal(file)
+	err = os.WriteFile(f, data, 0600)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := (&EncryptedFileCredentials{Path: f, Passphrase: []byte("passphrase")}).Credentials(context.Background(), nil)
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		if err == nil || !strings.Contains(err.Error(), "iterations") {
+			t.Errorf("Too many iterations should be rejected: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("We are deriving the key of a file with too many iterations")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	got := hex.EncodeToString(deriveKey([]byte("password"), []byte("salt"), 4096))
+	want := "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"
+	if got != want {
+		t.Errorf("deriveKey() = %s, want %s", got, want)
+	}
+}
+
+func TestApiCallCredentialsRotation(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+		seen = append(seen, password)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	t.Setenv("TEST_WSAPI_PASSWORD", "first")
+	apiClient, err := NewClient(server.URL+"/api", "user", "ignored", true, "NONE", WithCredentials(EnvCredentials{PasswordVar: "TEST_WSAPI_PASSWORD"}))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	for _, password := range []string{"first", "second"} {
+		t.Setenv("TEST_WSAPI_PASSWORD", password)
+		body, err := apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		body.Close()
+	}
+	if len(seen) != 2 || seen[0] != "first" || seen[1] != "second" {
+		t.Errorf("The rotated password wasn't picked up: %#v", seen)
+	}
+}
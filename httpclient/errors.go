@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors that we can use with errors.Is in order to know which kind
+// of failure the API of VmWare Workstation Pro give us, without string-matching
+// the messages.
+var (
+	// ErrNotFound the resource (VM, NIC, parameter...) doesn't exist.
+	ErrNotFound = errors.New("resource not found")
+	// ErrConflict the request collides with the current state of the resource.
+	ErrConflict = errors.New("resource conflict")
+	// ErrUnauthorized the credentials were rejected by the API.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrVMBusy the VM is locked or in use by another operation.
+	ErrVMBusy = errors.New("vm is busy")
+	// ErrServerUnavailable the API server isn't reachable or it can't attend us right now.
+	ErrServerUnavailable = errors.New("server unavailable")
+)
+
+// busyMessages are the fragments that the vmrest server use in the message of
+// a VmError when the VM is locked by other operation.
+var busyMessages = []string{"busy", "in use", "locked", "being used"}
+
+// APIError is the error that ApiCall give us when the API of VmWare Workstation
+// answer with a status code that isn't a success.
+// StatusCode: (int) The HTTP status code of the response.
+// Code: (int) The VmError code that vmrest has sent in the body, 0 if there isn't.
+// Message: (string) The VmError message, or the HTTP status text if the body was empty.
+// Method: (string) The HTTP method of the request.
+// Path: (string) The path of the API that we have called.
+type APIError struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Method     string
+	Path       string
+}
+
+// Error method to implement the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: status code %d", e.Method, e.Path, e.StatusCode)
+	if e.Code != 0 {
+		msg += fmt.Sprintf(", vmrest code %d", e.Code)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is method allow to use errors.Is with the sentinel errors of this package.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrVMBusy:
+		return e.StatusCode == http.StatusConflict && e.isBusyMessage()
+	case ErrServerUnavailable:
+		return e.StatusCode == http.StatusBadGateway ||
+			e.StatusCode == http.StatusServiceUnavailable ||
+			e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// isBusyMessage return true when the message of the VmError says that the VM is in use.
+func (e *APIError) isBusyMessage() bool {
+	msg := strings.ToLower(e.Message)
+	for _, fragment := range busyMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIError Auxiliary function to create an APIError from the response of the API.
+// Inputs:
+// m: (string) The HTTP method of the request.
+// p: (string) The path that we have called.
+// s: (int) The HTTP status code of the response.
+// vmerror: (*VmError) The error decoded from the body of the response, nil if there isn't.
+// Outputs:
+// (*APIError) The error filled with all the information that we have.
+func newAPIError(m string, p string, s int, vmerror *VmError) *APIError {
+	apierr := &APIError{
+		StatusCode: s,
+		Method:     m,
+		Path:       p,
+	}
+	if vmerror != nil {
+		apierr.Code = vmerror.Code
+		apierr.Message = vmerror.Message
+	}
+	if apierr.Message == "" {
+		apierr.Message = http.StatusText(s)
+	}
+	return apierr
+}
@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *APIError
+		target error
+		want   bool
+	}{
+		{"not found", &APIError{StatusCode: http.StatusNotFound}, ErrNotFound, true},
+		{"conflict", &APIError{StatusCode: http.StatusConflict}, ErrConflict, true},
+		{"conflict isn't busy", &APIError{StatusCode: http.StatusConflict, Message: "Invalid state"}, ErrVMBusy, false},
+		{"busy", &APIError{StatusCode: http.StatusConflict, Message: "The virtual machine is in use"}, ErrVMBusy, true},
+		{"unauthorized", &APIError{StatusCode: http.StatusUnauthorized}, ErrUnauthorized, true},
+		{"forbidden", &APIError{StatusCode: http.StatusForbidden}, ErrUnauthorized, true},
+		{"unavailable", &APIError{StatusCode: http.StatusServiceUnavailable}, ErrServerUnavailable, true},
+		{"internal isn't unavailable", &APIError{StatusCode: http.StatusInternalServerError}, ErrServerUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := fmt.Errorf("wrapped: %w", tt.err)
+			if got := errors.Is(wrapped, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	err := newAPIError("PUT", "vms/ABC/power", http.StatusConflict, &VmError{Code: 107, Message: "busy"})
+	want := "PUT vms/ABC/power: status code 409, vmrest code 107: busy"
+	if err.Error() != want {
+		t.Errorf("Error() = %#v, want %#v", err.Error(), want)
+	}
+	err = newAPIError("GET", "vms", http.StatusNotFound, nil)
+	if err.Message != http.StatusText(http.StatusNotFound) {
+		t.Errorf("Message = %#v, want the status text", err.Message)
+	}
+}
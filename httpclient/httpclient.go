@@ -2,15 +2,16 @@ package httpclient
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -36,6 +37,9 @@ type VmError struct {
 // BaseURL: (*url.URL) Object URL to storage URL to server.
 // User: (string) Name of user to authenticate in server.
 // Password: (string) Password of user, Debug: bool that show the debug it's active or not.
+// Retry: (*RetryPolicy) How to retry the failed calls, nil means that we never retry.
+// Credentials: (CredentialProvider) Where we get the User and Password in each call, if it's nil we use the fields.
+// Redactor: (*Redactor) Mask the secrets in the logs, NewClient creates one for each client if it's nil.
 type HTTPClient struct {
 	Client       *http.Client
 	BaseURL      *url.URL
@@ -43,6 +47,14 @@ type HTTPClient struct {
 	Password     string
 	InsecureFlag bool
 	DebugLevel   string
+	Retry        *RetryPolicy
+	Credentials  CredentialProvider
+	Redactor     *Redactor
+	baseClient   *http.Client
+	middlewares  []Middleware
+	tlsOptions   *TLSOptions
+	logger       *zerolog.Logger
+	flights      *flightGroup
 }
 
 // NewClient constructor of the Client object
@@ -52,14 +64,22 @@ type HTTPClient struct {
 // p: (string) String with the password.
 // i: (bool) True if we have generated the https certificates in our API.
 // d: (string) Level of Debug that we want.
+// opts: (...Option) Optional settings like middlewares or a custom *http.Client.
 // Outputs:
 // *Client: (pointer) Pointer at the object Client,
 // error: (error) when the client generate some error is storage in this var.
-func NewClient(a string, u string, p string, i bool, d string) (*HTTPClient, error) {
+func NewClient(a string, u string, p string, i bool, d string, opts ...Option) (*HTTPClient, error) {
 	c := new(HTTPClient)
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			c.Log().Error().Err(err).Msg("We can't apply the option to the client.")
+			return nil, err
+		}
+	}
 	URL, err := url.Parse(strings.TrimSpace(a))
 	if err != nil {
-		log.Error().Err(err).Msgf("We can't parsed the URL: %#v", err)
+		c.Log().Error().Err(err).Msgf("We can't parsed the URL: %#v", err)
 		return nil, err
 	}
 	c.BaseURL = URL
@@ -67,25 +87,26 @@ func NewClient(a string, u string, p string, i bool, d string) (*HTTPClient, err
 	c.Password = p
 	c.InsecureFlag = i
 	c.DebugLevel = (strings.ToUpper(d))
-	log.Debug().Msgf("Input values %#v, %#v, %#v, %#v, %#v", a, u, p, i, d)
-	c.Client = &http.Client{
-		Transport: &http.Transport{
-			// DisableKeepAlives: false,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: i,
-			},
-		},
-	}
-	log.Debug().Msgf("Client %#v", c.Client)
-	log.Info().Msg("We have created the client.")
+	if c.Redactor == nil {
+		c.Redactor = NewRedactor()
+	}
+	c.setSecret(p)
+	c.Log().Debug().Msgf("Input values %#v, %#v, %#v, %#v, %#v", URL.Redacted(), u, RedactedValue, i, d)
+	err = c.buildClient()
+	if err != nil {
+		c.Log().Error().Err(err).Msg("We can't build the http client.")
+		return nil, err
+	}
+	c.Log().Debug().Msgf("Client with timeout %s and transport %T", c.Client.Timeout, c.Client.Transport)
+	c.Log().Info().Msg("We have created the client.")
 	return c, nil
 }
 
 // New constructor of the Client object without input, this method generate a *Client
 // with values by default, Return: *Client: pointer at the object Client,
 // error: when the client generate some error is storage in this var.
-func New() (*HTTPClient, error) {
-	c, err := NewClient(defaultBaseURL, defaultUser, defaultPassword, defaultInsecure, defaultDebugLevel)
+func New(opts ...Option) (*HTTPClient, error) {
+	c, err := NewClient(defaultBaseURL, defaultUser, defaultPassword, defaultInsecure, defaultDebugLevel, opts...)
 	if err != nil {
 		log.Error().Err(err).Msg("We can't create the client")
 		return nil, err
@@ -103,29 +124,28 @@ func New() (*HTTPClient, error) {
 // d: (string) debug mode
 func (c *HTTPClient) ConfigClient(a string, u string, p string, i bool, d string) error {
 	var err error
-	log.Debug().Msgf("Variables Values: %#v, %#v, %#v, %#v, %#v", a, u, p, i, d)
+	c.setSecret(p)
 	c.BaseURL, err = url.Parse(a)
 	if err != nil {
-		log.Error().Err(err).Msg("The URL is malformed")
+		c.Log().Error().Err(err).Msg("The URL is malformed")
 		return err
 	}
-	log.Debug().Msgf("Client BaseURL: %#v", c.BaseURL)
+	c.Log().Debug().Msgf("Variables Values: %#v, %#v, %#v, %#v, %#v", c.BaseURL.Redacted(), u, RedactedValue, i, d)
+	c.Log().Debug().Msgf("Client BaseURL: %#v", c.BaseURL.Redacted())
 	c.User = u
-	log.Debug().Msgf("Client User: %#v", c.User)
+	c.Log().Debug().Msgf("Client User: %#v", c.User)
 	c.Password = p
-	log.Debug().Msgf("Client Password: %#v", c.Password)
+	c.Log().Debug().Msgf("Client Password: %#v", RedactedValue)
 	c.InsecureFlag = i
-	log.Debug().Msgf("Client http/s: %#v", c.InsecureFlag)
+	c.Log().Debug().Msgf("Client http/s: %#v", c.InsecureFlag)
 	c.DebugLevel = d
-	log.Debug().Msgf("Client Debug Level: %#v", c.DebugLevel)
-	c.Client = &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: i,
-			},
-		},
-	}
-	log.Info().Msgf("We have configured the client.")
+	c.Log().Debug().Msgf("Client Debug Level: %#v", c.DebugLevel)
+	err = c.buildClient()
+	if err != nil {
+		c.Log().Error().Err(err).Msg("We can't build the http client.")
+		return err
+	}
+	c.Log().Info().Msgf("We have configured the client.")
 	return nil
 }
 
@@ -137,18 +157,95 @@ func (c *HTTPClient) ConfigClient(a string, u string, p string, i bool, d string
 // pl: (bytes.Buffer) for read the Body of the request.
 // Output:
 // response: (io.ReadCloser) That will be the Response Body that the API give us.
-// err: (error) Normal error answer that go-lang give us in a issue.
+// err: (error) An *APIError when the API answer with a failure, you can use errors.Is
+// with ErrNotFound, ErrConflict, ErrUnauthorized, ErrVMBusy or ErrServerUnavailable.
 func (c *HTTPClient) ApiCall(p string, m string, pl bytes.Buffer) (io.ReadCloser, error) {
+	return c.ApiCallContext(context.Background(), p, m, pl)
+}
+
+// ApiCallContext is the same as ApiCall but the request is bound to ctx,
+// so it can be cancelled or limited with a deadline.
+func (c *HTTPClient) ApiCallContext(ctx context.Context, p string, m string, pl bytes.Buffer) (io.ReadCloser, error) {
+	payload := pl.Bytes()
+	if len(payload) > 0 {
+		c.Log().Debug().Msgf("Request Buffer: %#v", c.Redact(pl.String()))
+	}
+	if m != "GET" || c.flights == nil {
+		// A change in the server make old the responses of the GET calls in progress,
+		// also the ones that start while we are waiting for the change
+		c.flights.forget()
+		defer c.flights.forget()
+		return c.apiCall(ctx, p, m, payload)
+	}
+	body, joined, err := c.flights.do(ctx, c.RequestPath(p), func(ctx context.Context) ([]byte, error) {
+		response, err := c.apiCall(ctx, p, m, payload)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Close()
+		return io.ReadAll(response)
+	})
+	if err != nil {
+		if ctxerr := ctx.Err(); ctxerr != nil && errors.Is(err, ctxerr) {
+			return nil, fmt.Errorf("%s %s: %w", m, p, ctxerr)
+		}
+		return nil, err
+	}
+	if joined {
+		c.Log().Debug().Msgf("We have shared the API call %s %s with other callers", m, p)
+	}
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// apiCall method make the API call with the retries of the RetryPolicy.
+func (c *HTTPClient) apiCall(ctx context.Context, p string, m string, payload []byte) (io.ReadCloser, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := c.doApiCall(ctx, p, m, payload)
+		if err == nil {
+			return response, nil
+		}
+		delay, retry := c.Retry.next(attempt, m, p, err)
+		c.Retry.observe(RetryAttempt{
+			Attempt:   attempt,
+			Method:    m,
+			Path:      p,
+			Err:       err,
+			Delay:     delay,
+			WillRetry: retry,
+		})
+		if !retry {
+			return nil, err
+		}
+		c.Log().Debug().Msgf("Attempt %#v failed, we will retry the API call in %s", attempt, delay)
+		err = sleepContext(ctx, delay)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", m, p, err)
+		}
+	}
+}
+
+// doApiCall method make just one attempt of the API call that ApiCallContext needs.
+// Input:
+// ctx: (context.Context) The context that bound the request.
+// p: (string) URL path of the API REST of the sever.
+// m: (string) Type of method GET, PUT, POST, DELETE.
+// payload: ([]byte) The Body of the request.
+// Output:
+// response: (io.ReadCloser) That will be the Response Body that the API give us.
+// err: (error) An *APIError when the API answer with a failure.
+func (c *HTTPClient) doApiCall(ctx context.Context, p string, m string, payload []byte) (io.ReadCloser, error) {
 	var vmerror VmError
-	req, err := http.NewRequest(m, c.RequestPath(p), &pl)
+	req, err := http.NewRequestWithContext(ctx, m, c.RequestPath(p), bytes.NewReader(payload))
 	if err != nil {
-		log.Error().Err(err).Msgf("Calling to API: %#v", err)
+		c.Log().Error().Err(err).Msgf("Calling to API: %#v", err)
 		return nil, err
 	}
-	if pl.Len() > 0 {
-		log.Debug().Msgf("Request Buffer: %#v", pl.String())
+	user, password, err := c.credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: resolving the credentials: %w", m, p, err)
 	}
-	req.SetBasicAuth(c.User, c.Password)
+	c.setSecret(password)
+	req.SetBasicAuth(user, password)
 	switch m {
 	case "GET":
 		req.Header.Add("Content-Type", "application/vnd.vmware.vmw.rest-v1+json")
@@ -160,57 +257,44 @@ func (c *HTTPClient) ApiCall(p string, m string, pl bytes.Buffer) (io.ReadCloser
 	default:
 		req.Header.Add("Content-Type", "application/json")
 	}
-	log.Debug().Msgf("We are doing the API call")
+	c.Log().Debug().Msgf("We are doing the API call")
 	responseBody := new(bytes.Buffer)
 	response, err := c.Client.Do(req)
-	if response == nil && err != nil {
-		log.Error().Err(err).Msg("The server response with timeout.")
-		return nil, err
+	if err != nil {
+		c.Log().Error().Err(err).Msg("The server response with timeout.")
+		if ctxerr := ctx.Err(); ctxerr != nil {
+			return nil, fmt.Errorf("%s %s: %w", m, p, ctxerr)
+		}
+		if errors.Is(err, ErrCassetteMismatch) {
+			return nil, fmt.Errorf("%s %s: %w", m, p, err)
+		}
+		if isTLSError(err) {
+			return nil, fmt.Errorf("%s %s: %w, check the CA or the pinned fingerprint of the server: %w", m, p, ErrTLSVerification, err)
+		}
+		return nil, fmt.Errorf("%s %s: %w: %w", m, p, ErrServerUnavailable, err)
 	}
-	log.Debug().Msgf("Response RAW %#v", response)
+	c.Log().Debug().Msgf("Response RAW %s %#v", response.Status, c.redactor().Header(response.Header))
 	switch response.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
-		log.Debug().Msgf("The result of API call was: %#v", response.StatusCode)
-	case http.StatusConflict:
-		err = json.NewDecoder(response.Body).Decode(&vmerror)
-		if err != nil {
-			log.Error().Err(err).Msg("Trying to decode the VmError.")
-			return nil, err
-		}
-		log.Debug().Msgf("Response StatusCode %#v Code Error %#v Message: %#v", response.StatusCode, vmerror.Code, vmerror.Message)
-		// We need to create a new functionality to handle the different errors that the VmWare Workstation API give us when we have an error
-		return nil, errors.New("StatusCode:" + strconv.Itoa(response.StatusCode) + ", Code Error:" + strconv.Itoa(vmerror.Code) + ", Message:" + vmerror.Message)
-	case http.StatusNotFound:
-		log.Debug().Msgf("Response StatusCode %#v Resource Not Found, the VmWare API isn't working properly", response.StatusCode)
-		// We need to create a new functionality to handle the different errors that the VmWare Workstation API give us when we have an error
-		return nil, errors.New("StatusCode:" + strconv.Itoa(response.StatusCode) + " Resource Not Found, the VmWare API isn't working properly")
-	case http.StatusInternalServerError:
-		err = json.NewDecoder(response.Body).Decode(&vmerror)
-		if err != nil {
-			log.Error().Err(err).Msg("Trying to decode the VmError.")
-			return nil, err
-		}
-		log.Debug().Msgf("Response StatusCode %#v Code Error %#v Message: %#v", response.StatusCode, vmerror.Code, vmerror.Message)
-		// We need to create a new functionality to handle the different errors that the VmWare Workstation API give us when we have an error
-		return nil, errors.New("StatusCode:" + strconv.Itoa(response.StatusCode) + ", Code Error:" + strconv.Itoa(vmerror.Code) + ", Message:" + vmerror.Message)
+		c.Log().Debug().Msgf("The result of API call was: %#v", response.StatusCode)
 	default:
+		defer response.Body.Close()
 		_, err = responseBody.ReadFrom(response.Body)
 		if err != nil {
-			log.Error().Err(err).Msgf("ResponseBody RAW %#v", responseBody)
-			return nil, err
+			c.Log().Error().Err(err).Msgf("ResponseBody RAW %#v", c.Redact(responseBody.String()))
+			return nil, fmt.Errorf("%w: %w", newAPIError(m, p, response.StatusCode, nil), err)
 		}
-		err = json.NewDecoder(responseBody).Decode(&vmerror)
-		if err != nil {
-			log.Error().Err(err).Msg("The Response isn't a JSON format.")
-			return nil, err
+		if responseBody.Len() > 0 {
+			err = json.Unmarshal(responseBody.Bytes(), &vmerror)
+			if err != nil {
+				c.Log().Debug().Msgf("The Response isn't a VmError in JSON format: %#v", c.Redact(responseBody.String()))
+				vmerror.Message = strings.TrimSpace(responseBody.String())
+			}
 		}
-		// We need to create a new functionality to handle the different errors that the VmWare Workstation API give us when we have an error
-		return nil, errors.New("StatusCode:" + strconv.Itoa(response.StatusCode) + ", Code Error:" + strconv.Itoa(vmerror.Code) + ", Message:" + vmerror.Message)
-	}
-	if err != nil {
-		log.Error().Err(err).Msg("Error making a call")
-		return nil, err
+		apierr := newAPIError(m, p, response.StatusCode, &vmerror)
+		c.Log().Debug().Msgf("Response StatusCode %#v Code Error %#v Message: %#v", apierr.StatusCode, apierr.Code, apierr.Message)
+		return nil, apierr
 	}
-	log.Debug().Msg("The API call was completed.")
+	c.Log().Debug().Msg("The API call was completed.")
 	return response.Body, nil
 }
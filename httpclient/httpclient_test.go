@@ -1,8 +1,16 @@
 package httpclient
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
 )
 
 func TestNewClient(t *testing.T) {
@@ -24,13 +32,103 @@ func TestNewClient(t *testing.T) {
 }
 
 func TestNew(t *testing.T) {
-
+	apiClient, err := New()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if apiClient.BaseURL.String() != defaultBaseURL || apiClient.User != defaultUser || apiClient.Password != defaultPassword {
+		t.Errorf("The client hasn't the default values: %#v", apiClient)
+	}
 }
 
 func TestApiCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/vms":
+			w.Write([]byte(`[]`))
+		case "/api/vms/BUSY/power":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":107,"message":"The virtual machine is in use"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, err := apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body.Close()
+	_, err = apiClient.ApiCall("vms/BUSY/power", "PUT", *bytes.NewBufferString("on"))
+	var apierr *APIError
+	if !errors.As(err, &apierr) {
+		t.Fatalf("The error isn't an APIError: %#v", err)
+	}
+	if apierr.Code != 107 || apierr.Method != "PUT" || apierr.Path != "vms/BUSY/power" {
+		t.Errorf("The APIError hasn't the expected fields: %#v", apierr)
+	}
+	if !errors.Is(err, ErrVMBusy) || !errors.Is(err, ErrConflict) {
+		t.Errorf("The error should be ErrVMBusy and ErrConflict: %#v", err)
+	}
+	_, err = apiClient.ApiCall("vms/MISSING", "GET", bytes.Buffer{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+	server.Close()
+	_, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if !errors.Is(err, ErrServerUnavailable) {
+		t.Errorf("The error should be ErrServerUnavailable: %#v", err)
+	}
+}
 
+func TestApiCallContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = apiClient.ApiCallContext(ctx, "vms", "GET", bytes.Buffer{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("The error should be context.DeadlineExceeded: %#v", err)
+	}
 }
 
 func TestConfigCli(t *testing.T) {
-
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	apiClient, err := New()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = apiClient.ConfigClient(server.URL, server.User, server.Password, false, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, err := apiClient.ApiCall("vms/VMID", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body.Close()
+	err = apiClient.ConfigClient(server.URL, server.User, "wrong", false, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, err = apiClient.ApiCall("vms/VMID", "GET", bytes.Buffer{})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("The error should be ErrUnauthorized: %#v", err)
+	}
 }
@@ -25,8 +25,8 @@ func (c *HTTPClient) SwitchDebugLevel(l string) {
 	default:
 		c.DebugLevel = "NONE"
 	}
-	log.Debug().Str("level", c.DebugLevel).Msg("We have changed the Log Level at: ")
-	log.Info().Msg("We have changed the Log Level.")
+	c.Log().Debug().Str("level", c.DebugLevel).Msg("We have changed the Log Level at: ")
+	c.Log().Info().Msg("We have changed the Log Level.")
 }
 
 // requestPath method show the URL to the request of httpClient.
@@ -36,7 +36,7 @@ func (c *HTTPClient) SwitchDebugLevel(l string) {
 // (string) with the complete URL to access
 func (c *HTTPClient) RequestPath(p string) string {
 	r := fmt.Sprintf("%s/%s", c.BaseURL, p)
-	log.Debug().Str("URL", c.BaseURL.Host+"/"+p).Msg("The whole endpoint that we will visit.")
+	c.Log().Debug().Str("URL", c.BaseURL.Host+"/"+p).Msg("The whole endpoint that we will visit.")
 	return r
 }
 
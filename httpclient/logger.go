@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// WithLogger option set the logger that the client will use, in this way each
+// client can log in a different place and we don't touch the global logger.
+// Inputs:
+// l: (zerolog.Logger) The logger that we want to use.
+func WithLogger(l zerolog.Logger) Option {
+	return func(c *HTTPClient) error {
+		c.SetLogger(&l)
+		return nil
+	}
+}
+
+// WithSlogHandler option send all the logs of the client to a log/slog handler.
+// Inputs:
+// h: (slog.Handler) The handler that will receive the records.
+func WithSlogHandler(h slog.Handler) Option {
+	return func(c *HTTPClient) error {
+		if h == nil {
+			return errors.New("the slog handler can't be nil")
+		}
+		l := NewSlogAdapter(h)
+		c.SetLogger(&l)
+		return nil
+	}
+}
+
+// Log method return the logger of the client, if we haven't set one we use the
+// global logger of zerolog, that's the behavior that the library always had.
+// Outputs:
+// (*zerolog.Logger) The logger that we have to use.
+func (c *HTTPClient) Log() *zerolog.Logger {
+	if c == nil || c.logger == nil {
+		return &log.Logger
+	}
+	return c.logger
+}
+
+// SetLogger method change the logger of the client, nil means that we go back
+// to the global logger of zerolog.
+// Inputs:
+// l: (*zerolog.Logger) The logger that we want to use.
+func (c *HTTPClient) SetLogger(l *zerolog.Logger) {
+	c.logger = l
+}
+
+// NewSlogAdapter function create a zerolog.Logger that write all its events in
+// a log/slog handler, the fields of the event are sent as attributes of the record.
+// Inputs:
+// h: (slog.Handler) The handler that will receive the records.
+// Outputs:
+// (zerolog.Logger) The logger that we can use in WithLogger or SetLogger.
+func NewSlogAdapter(h slog.Handler) zerolog.Logger {
+	return zerolog.New(slogWriter{handler: h})
+}
+
+// slogWriter is the zerolog.LevelWriter that translate the JSON events of
+// zerolog to records of log/slog.
+type slogWriter struct {
+	handler slog.Handler
+}
+
+// Write method to implement the io.Writer interface, zerolog always call
+// WriteLevel so we just use the info level here.
+func (w slogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.InfoLevel, p)
+}
+
+// WriteLevel method to implement the zerolog.LevelWriter interface.
+func (w slogWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	ctx := context.Background()
+	level := slogLevel(l)
+	if !w.handler.Enabled(ctx, level) {
+		return len(p), nil
+	}
+	var event map[string]any
+	err := json.Unmarshal(p, &event)
+	if err != nil {
+		return 0, err
+	}
+	msg, _ := event[zerolog.MessageFieldName].(string)
+	delete(event, zerolog.MessageFieldName)
+	delete(event, zerolog.LevelFieldName)
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	for key, value := range event {
+		record.AddAttrs(slog.Any(key, value))
+	}
+	err = w.handler.Handle(ctx, record)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// slogLevel Auxiliary function to convert the levels of zerolog in levels of slog.
+func slogLevel(l zerolog.Level) slog.Level {
+	switch {
+	case l <= zerolog.DebugLevel:
+		return slog.LevelDebug
+	case l == zerolog.InfoLevel, l == zerolog.NoLevel:
+		return slog.LevelInfo
+	case l == zerolog.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,75 @@
+package httpclient
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestWithLogger(t *testing.T) {
+	var global, first, second bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&global)
+	defer func() { log.Logger = previous }()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	firstClient, err := NewClient(server.URL+"/api", "admin", "password", true, "DEBUG", WithLogger(zerolog.New(&first)))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	secondClient, err := NewClient(server.URL+"/api", "admin", "password", true, "DEBUG", WithLogger(zerolog.New(&second).Level(zerolog.InfoLevel)))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	for _, c := range []*HTTPClient{firstClient, secondClient} {
+		body, err := c.ApiCall("vms", "GET", bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		body.Close()
+	}
+	if global.Len() != 0 {
+		t.Errorf("The clients have written in the global logger:\n%s", global.String())
+	}
+	if !strings.Contains(first.String(), `"level":"debug"`) {
+		t.Errorf("The first client hasn't written debug messages:\n%s", first.String())
+	}
+	if strings.Contains(second.String(), `"level":"debug"`) || second.Len() == 0 {
+		t.Errorf("The second client should write just info messages:\n%s", second.String())
+	}
+	firstClient.SetLogger(nil)
+	if firstClient.Log() != &log.Logger {
+		t.Errorf("Without logger the client should use the global logger")
+	}
+}
+
+func TestWithSlogHandler(t *testing.T) {
+	var logs bytes.Buffer
+	handler := slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})
+	c, err := New(WithSlogHandler(handler))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	c.Log().Debug().Msg("hidden message")
+	c.Log().Warn().Str("vm", "ID").Msg("visible message")
+	if strings.Contains(logs.String(), "hidden message") {
+		t.Errorf("The handler shouldn't receive the debug records:\n%s", logs.String())
+	}
+	for _, want := range []string{`"level":"WARN"`, `"msg":"visible message"`, `"vm":"ID"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("The record doesn't contain %s:\n%s", want, logs.String())
+		}
+	}
+	_, err = New(WithSlogHandler(nil))
+	if err == nil {
+		t.Errorf("A nil handler should be an error")
+	}
+}
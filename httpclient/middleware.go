@@ -0,0 +1,122 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Option is a function that we can use to change the settings of the HTTPClient
+// when we create it with NewClient or later with the Apply method.
+type Option func(c *HTTPClient) error
+
+// Middleware is a function that wrap an http.RoundTripper with another one, in this
+// way we can inject auth headers, logging, metrics or faults in all the API calls.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use an ordinary function as http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip method to implement the http.RoundTripper interface.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware option add the middlewares at the end of the chain, the first
+// middleware of the chain is the first one that see the request.
+// Inputs:
+// mw: (...Middleware) The middlewares that we want to add.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *HTTPClient) error {
+		for _, m := range mw {
+			if m == nil {
+				return errors.New("the middleware can't be nil")
+			}
+		}
+		c.middlewares = append(c.middlewares, mw...)
+		return nil
+	}
+}
+
+// WithHTTPClient option use hc as base of the http client, we keep its settings,
+// like the Timeout or the Jar, and if its Transport is an *http.Transport we
+// just change the TLS settings that the library manages.
+// Inputs:
+// hc: (*http.Client) The http client that we want to use.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *HTTPClient) error {
+		if hc == nil {
+			return errors.New("the http client can't be nil")
+		}
+		c.baseClient = hc
+		return nil
+	}
+}
+
+// WithRetryPolicy option set the policy to retry the API calls that fail.
+// Inputs:
+// rp: (*RetryPolicy) The policy, nil disable the retries.
+func WithRetryPolicy(rp *RetryPolicy) Option {
+	return func(c *HTTPClient) error {
+		c.Retry = rp
+		return nil
+	}
+}
+
+// Apply method change the settings of the client with the options and
+// build again the http client with all of them.
+// Inputs:
+// opts: (...Option) The options that we want to apply.
+// Outputs:
+// error: (error) If some option isn't valid.
+func (c *HTTPClient) Apply(opts ...Option) error {
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return err
+		}
+	}
+	return c.buildClient()
+}
+
+// buildClient method create the http client that we use in the API calls, first
+// the transport managed by the library and after that the chain of middlewares.
+func (c *HTTPClient) buildClient() error {
+	client := new(http.Client)
+	var transport http.RoundTripper
+	if c.baseClient != nil {
+		*client = *c.baseClient
+		transport = c.baseClient.Transport
+	}
+	transport, err := c.managedTransport(transport)
+	if err != nil {
+		return err
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		transport = c.middlewares[i](transport)
+	}
+	client.Transport = transport
+	c.Client = client
+	c.Log().Debug().Msgf("We have built the http client with %#v middlewares", len(c.middlewares))
+	return nil
+}
+
+// managedTransport method apply the TLS settings of the library in the transport rt,
+// if rt isn't an *http.Transport we can't change it and we use it like it is.
+func (c *HTTPClient) managedTransport(rt http.RoundTripper) (http.RoundTripper, error) {
+	var transport *http.Transport
+	switch t := rt.(type) {
+	case nil:
+		transport = new(http.Transport)
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		c.Log().Debug().Msg("The base transport isn't an *http.Transport, we can't manage the TLS settings.")
+		return rt, nil
+	}
+	config, err := c.tlsConfig(transport.TLSClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = config
+	return transport, nil
+}
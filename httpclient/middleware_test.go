@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("X-Trace"))
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE", WithMiddleware(trace("a"), trace("b")))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, err := apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body.Close()
+	if len(order) != 2 || order[0] != "a" || order[1] != "b" {
+		t.Errorf("The middlewares were called in the wrong order: %#v", order)
+	}
+	if len(seen) != 1 || seen[0] != "ab" {
+		t.Errorf("The server hasn't received the header of the middlewares: %#v", seen)
+	}
+	// The middlewares must survive at the reconfiguration of the client
+	err = apiClient.ConfigClient(server.URL+"/api", "user", "pass", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	body.Close()
+	if len(order) != 4 {
+		t.Errorf("The middlewares were lost after ConfigClient: %#v", order)
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	base := &http.Client{
+		Timeout: 3 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{ServerName: "vmrest.local"},
+		},
+	}
+	apiClient, err := NewClient("https://localhost:8697/api", "user", "pass", true, "NONE", WithHTTPClient(base))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if apiClient.Client.Timeout != base.Timeout {
+		t.Errorf("The Timeout of the custom client was lost: %#v", apiClient.Client.Timeout)
+	}
+	transport, ok := apiClient.Client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("The transport should be an *http.Transport: %#v", apiClient.Client.Transport)
+	}
+	if !transport.TLSClientConfig.InsecureSkipVerify || transport.TLSClientConfig.ServerName != "vmrest.local" {
+		t.Errorf("The TLS settings weren't merged: %#v", transport.TLSClientConfig)
+	}
+	if base.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify {
+		t.Errorf("The transport of the caller shouldn't be modified")
+	}
+	_, err = NewClient("https://localhost:8697/api", "user", "pass", true, "NONE", WithHTTPClient(nil))
+	if err == nil {
+		t.Errorf("A nil http client should be an error")
+	}
+}
@@ -0,0 +1,64 @@
+package httpclient
+
+import "github.com/elsudano/vmware-workstation-api-client/internal/redact"
+
+// RedactedValue is the text that we write in the logs instead of a secret.
+const RedactedValue = redact.Value
+
+// Redactor object, this object mask the secrets before we write them in the logs.
+// The secrets can be literal values, like the password of the client, or the
+// values of the sensitive keys, like the Authorization header or the guestinfo.*
+// parameters of the vmx files.
+type Redactor = redact.Redactor
+
+// DefaultRedactor is the Redactor of the functions like Redact, that we use out of the
+// clients. Each client has its own Redactor, so their passwords never end here.
+var DefaultRedactor = redact.Default
+
+// NewRedactor constructor of the Redactor object with the default sensitive keys
+// and the extra keys that we want.
+// Inputs:
+// keys: (...string) Extra sensitive keys, like "guestinfo.*" or "annotation".
+func NewRedactor(keys ...string) *Redactor {
+	return redact.New(keys...)
+}
+
+// WithSensitiveKeys option add extra sensitive keys at the Redactor of the client.
+// Inputs:
+// keys: (...string) The keys whose values we want to mask, like "guestinfo.*".
+func WithSensitiveKeys(keys ...string) Option {
+	return func(c *HTTPClient) error {
+		if c.Redactor == nil {
+			c.Redactor = NewRedactor()
+		}
+		c.Redactor.AddSensitiveKeys(keys...)
+		return nil
+	}
+}
+
+// Redact function mask the secrets of s with the DefaultRedactor.
+func Redact(s string) string {
+	return redact.String(s)
+}
+
+// redactor method return the Redactor of the client, the clients that we don't create
+// with NewClient use the DefaultRedactor but we never put their password in it.
+func (c *HTTPClient) redactor() *Redactor {
+	if c.Redactor == nil {
+		return DefaultRedactor
+	}
+	return c.Redactor
+}
+
+// setSecret method change the password that the Redactor of the client masks.
+func (c *HTTPClient) setSecret(p string) {
+	if c.Redactor != nil {
+		c.Redactor.SetSecret(p)
+	}
+}
+
+// Redact method mask the secrets of s with the Redactor of the client, the
+// packages that use the client call it before write the payloads in the logs.
+func (c *HTTPClient) Redact(s string) string {
+	return c.redactor().String(s)
+}
@@ -0,0 +1,83 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestNoSecretsInLogs(t *testing.T) {
+	const password = "s3cr3t-Pa55"
+	const guestSecret = "guest-S3cret-value"
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs).Level(zerolog.DebugLevel)
+	defer func() { log.Logger = previous }()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(r.URL.Path, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write(body)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+	apiClient, err := NewClient(server.URL+"/api", "admin", password, true, "DEBUG")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = apiClient.ConfigClient(strings.Replace(server.URL, "://", "://admin:"+password+"@", 1)+"/api", "admin", password, true, "DEBUG")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	payload := `{"name":"guestinfo.userdata","value":"` + guestSecret + `"}`
+	for _, path := range []string{"vms/ID/configparams", "vms/ID/fail"} {
+		body, err := apiClient.ApiCall(path, "PUT", *bytes.NewBufferString(payload))
+		if err == nil {
+			body.Close()
+		}
+	}
+	if logs.Len() == 0 {
+		t.Fatalf("We haven't captured any log")
+	}
+	for _, secret := range []string{password, guestSecret} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("The secret %q appears in the logs:\n%s", secret, logs.String())
+		}
+	}
+}
+
+func TestRedactorPerClient(t *testing.T) {
+	first, err := NewClient("http://localhost:8697/api", "admin", "first-Pa55", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	second, err := NewClient("http://localhost:8697/api", "admin", "second-Pa55", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	text := "first-Pa55 second-Pa55"
+	if got := first.Redact(text); got != RedactedValue+" second-Pa55" {
+		t.Errorf("The first client should only mask its password: %q", got)
+	}
+	if got := second.Redact(text); got != "first-Pa55 "+RedactedValue {
+		t.Errorf("The second client should only mask its password: %q", got)
+	}
+	if got := Redact(text); got != text {
+		t.Errorf("The passwords of the clients shouldn't be in the DefaultRedactor: %q", got)
+	}
+	err = first.ConfigClient("http://localhost:8697/api", "admin", "rotated-Pa55", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if got := first.Redact("first-Pa55 rotated-Pa55"); got != "first-Pa55 "+RedactedValue {
+		t.Errorf("The new password should replace the old one: %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 4
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryMultiplier     = 2.0
+	defaultRetryJitter         = 0.2
+)
+
+// RetryPolicy object, this object define how the HTTPClient retry the API calls
+// that fail because the vmrest server is busy or is having a transient problem.
+// MaxAttempts: (int) Number of attempts including the first one, 1 or less disable the retries.
+// InitialBackoff: (time.Duration) Time to wait before the second attempt.
+// MaxBackoff: (time.Duration) The maximum time that we will wait between attempts.
+// Multiplier: (float64) Factor to grow the backoff after each attempt.
+// Jitter: (float64) Fraction between 0 and 1 of the backoff that will be random.
+// RetryableStatus: ([]int) HTTP status codes that we consider transient.
+// RetryableCodes: ([]int) VmError codes that we consider transient.
+// RetryNonIdempotent: (bool) If true the POST calls are retried like the other ones,
+// otherwise we just retry them when we know that the server didn't process them.
+// Retryable: (func) Optional function to override the classification of the errors.
+// OnAttempt: (func) Optional hook that we call after each failed attempt.
+type RetryPolicy struct {
+	MaxAttempts        int
+	InitialBackoff     time.Duration
+	MaxBackoff         time.Duration
+	Multiplier         float64
+	Jitter             float64
+	RetryableStatus    []int
+	RetryableCodes     []int
+	RetryNonIdempotent bool
+	Retryable          func(m string, p string, err error) bool
+	OnAttempt          func(a RetryAttempt)
+}
+
+// RetryAttempt is the information that we give at the OnAttempt hook of the RetryPolicy.
+// Attempt: (int) The number of the attempt that has failed, starting in 1.
+// Method: (string) The HTTP method of the request.
+// Path: (string) The path of the API that we have called.
+// Err: (error) The error of this attempt.
+// Delay: (time.Duration) Time that we will wait before the next attempt.
+// WillRetry: (bool) True if we will make another attempt.
+type RetryAttempt struct {
+	Attempt   int
+	Method    string
+	Path      string
+	Err       error
+	Delay     time.Duration
+	WillRetry bool
+}
+
+// DefaultRetryPolicy function return a RetryPolicy with the values that work well
+// with the vmrest server, just after clone or change the power state of a VM.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		Multiplier:     defaultRetryMultiplier,
+		Jitter:         defaultRetryJitter,
+		RetryableStatus: []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// next method decide if we have to make another attempt after the error err,
+// and how long we have to wait before it.
+// Input:
+// attempt: (int) The number of the attempt that has failed.
+// m: (string) The HTTP method of the request.
+// p: (string) The path of the API that we have called.
+// err: (error) The error that the attempt give us.
+// Output:
+// (time.Duration) The time to wait before the next attempt.
+// (bool) True if we have to make another attempt.
+func (rp *RetryPolicy) next(attempt int, m string, p string, err error) (time.Duration, bool) {
+	if rp == nil || attempt >= rp.MaxAttempts {
+		return 0, false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+	var retry bool
+	if rp.Retryable != nil {
+		retry = rp.Retryable(m, p, err)
+	} else {
+		retry = rp.isRetryable(m, err)
+	}
+	if !retry {
+		return 0, false
+	}
+	return rp.backoff(attempt), true
+}
+
+// isRetryable method classify the error using the status codes, the VmError codes
+// and if the method is idempotent or not.
+func (rp *RetryPolicy) isRetryable(m string, err error) bool {
+	var apierr *APIError
+	if !errors.As(err, &apierr) {
+		// Here we don't have answer of the server, the only case that is safe to
+		// retry a not idempotent call is when we never got connected.
+		if !errors.Is(err, ErrServerUnavailable) {
+			return false
+		}
+		return isIdempotent(m) || rp.RetryNonIdempotent || isDialError(err)
+	}
+	transient := errors.Is(apierr, ErrVMBusy) || errors.Is(apierr, ErrServerUnavailable) ||
+		slices.Contains(rp.RetryableStatus, apierr.StatusCode) ||
+		(apierr.Code != 0 && slices.Contains(rp.RetryableCodes, apierr.Code))
+	if !transient {
+		return false
+	}
+	if isIdempotent(m) || rp.RetryNonIdempotent {
+		return true
+	}
+	// The server has rejected the call without do anything, so it's safe to repeat it
+	return errors.Is(apierr, ErrVMBusy) || apierr.StatusCode == http.StatusServiceUnavailable
+}
+
+// backoff method calculate the time to wait after the attempt with exponential growth and jitter.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := rp.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(rp.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if rp.MaxBackoff > 0 && delay > float64(rp.MaxBackoff) {
+		delay = float64(rp.MaxBackoff)
+	}
+	if rp.Jitter > 0 {
+		jitter := math.Min(rp.Jitter, 1)
+		delay = delay * (1 - jitter*rand.Float64())
+	}
+	return time.Duration(delay)
+}
+
+// observe method call the OnAttempt hook if we have one.
+func (rp *RetryPolicy) observe(a RetryAttempt) {
+	if rp == nil || rp.OnAttempt == nil {
+		return
+	}
+	rp.OnAttempt(a)
+}
+
+// isIdempotent function return true when repeat the call with the method m
+// doesn't change the result, like GET, PUT or DELETE.
+func isIdempotent(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isDialError function return true if the error happened while we were connecting,
+// so the server never received the request.
+func isDialError(err error) bool {
+	var operr *net.OpError
+	return errors.As(err, &operr) && operr.Op == "dial"
+}
+
+// sleepContext function wait the duration d or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func testRetryPolicy() *RetryPolicy {
+	rp := DefaultRetryPolicy()
+	rp.InitialBackoff = time.Millisecond
+	rp.MaxBackoff = 5 * time.Millisecond
+	return rp
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":107,"message":"The virtual machine is busy"}`))
+			return
+		}
+		w.Write([]byte(`{"power_state":"poweredOn"}`))
+	}))
+	defer server.Close()
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	var attempts []RetryAttempt
+	apiClient.Retry = testRetryPolicy()
+	apiClient.Retry.OnAttempt = func(a RetryAttempt) {
+		attempts = append(attempts, a)
+	}
+	body, err := apiClient.ApiCall("vms/ID/power", "PUT", *bytes.NewBufferString("on"))
+	if err != nil {
+		t.Fatalf("The call should be successful after retry: %#v", err)
+	}
+	body.Close()
+	if calls.Load() != 3 {
+		t.Errorf("We expected 3 calls, we have %#v", calls.Load())
+	}
+	if len(attempts) != 2 || !attempts[0].WillRetry || attempts[1].Attempt != 2 {
+		t.Errorf("The hook hasn't observed the failed attempts: %#v", attempts)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code":1,"message":"Unknown error"}`))
+	}))
+	defer server.Close()
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	apiClient.Retry = testRetryPolicy()
+	_, err = apiClient.ApiCall("vms", "POST", *bytes.NewBufferString(`{"name":"clone","parentId":"ID"}`))
+	var apierr *APIError
+	if !errors.As(err, &apierr) || apierr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("We expected the APIError of the server: %#v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("The clone shouldn't be repeated, we have %#v calls", calls.Load())
+	}
+	calls.Store(0)
+	_, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if err == nil {
+		t.Errorf("The call should fail")
+	}
+	if calls.Load() != int32(apiClient.Retry.MaxAttempts) {
+		t.Errorf("We expected %#v calls, we have %#v", apiClient.Retry.MaxAttempts, calls.Load())
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	rp := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, Multiplier: 2}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		if got := rp.backoff(attempt + 1); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt+1, got, want)
+		}
+	}
+	rp.Jitter = 0.5
+	for attempt := 1; attempt < 10; attempt++ {
+		got := rp.backoff(attempt)
+		if got < 500*time.Millisecond || got > 3*time.Second {
+			t.Errorf("backoff(%d) = %s is out of range", attempt, got)
+		}
+	}
+}
+
+func TestRetryWithFakeServer(t *testing.T) {
+	server := wsapitest.NewServer(wsapitest.VM{ID: "VMID"})
+	defer server.Close()
+	apiClient, err := NewClient(server.URL, server.User, server.Password, false, "NONE", WithRetryPolicy(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	server.On("GET", "vms/*/power").Times(2).Fail(http.StatusServiceUnavailable, 0, "Service unavailable")
+	server.On("PUT", "vms/*/power").Once().Busy()
+	server.On("POST", "vms").Fail(http.StatusInternalServerError, 0, "Internal error")
+	server.On("GET", "vms").Times(1).Drop()
+	body, err := apiClient.ApiCall("vms/VMID/power", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("The GET should succeed after the retries: %#v", err)
+	}
+	body.Close()
+	body, err = apiClient.ApiCall("vms/VMID/power", "PUT", *bytes.NewBufferString("on"))
+	if err != nil {
+		t.Fatalf("The busy VM should be retried: %#v", err)
+	}
+	body.Close()
+	_, err = apiClient.ApiCall("vms", "POST", *bytes.NewBufferString(`{"name":"clone","parentId":"VMID"}`))
+	if err == nil || server.CountRequests("POST", "vms") != 1 {
+		t.Errorf("The clone shouldn't be repeated: %#v", err)
+	}
+	body, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("The dropped GET should be retried: %#v", err)
+	}
+	body.Close()
+	if server.CountRequests("GET", "vms/VMID/power") != 3 || server.CountRequests("PUT", "vms/VMID/power") != 2 || server.CountRequests("GET", "vms") != 2 {
+		t.Errorf("We haven't made the expected attempts: %#v", server.Requests())
+	}
+}
@@ -0,0 +1,195 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrTLSVerification the certificate of the server couldn't be verified.
+	ErrTLSVerification = errors.New("tls verification failed")
+	// ErrCertificatePin the certificate of the server doesn't match any pinned fingerprint.
+	ErrCertificatePin = errors.New("certificate doesn't match the pinned fingerprints")
+)
+
+// TLSOptions object, this object contain the TLS settings to talk with a vmrest
+// server that use HTTPS, for example with the certificate that `make api_start` generates.
+// CAFile: (string) Path of a PEM bundle with the CAs, or directly the certificate of vmrest.
+// CAPEM: ([]byte) The same that CAFile but with the PEM content.
+// PinnedSHA256: ([]string) SHA-256 fingerprints of the certificates that we trust, in hex
+// with or without colons, like the output of `openssl x509 -noout -fingerprint -sha256`.
+// ServerName: (string) The name that we expect in the certificate, if it isn't the host of the URL.
+// MinVersion: (uint16) The minimum TLS version, tls.VersionTLS12 by default.
+// ClientCertFile: (string) Path of the PEM certificate to authenticate the client.
+// ClientKeyFile: (string) Path of the PEM private key of the client certificate.
+type TLSOptions struct {
+	CAFile         string
+	CAPEM          []byte
+	PinnedSHA256   []string
+	ServerName     string
+	MinVersion     uint16
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// WithTLS option set the TLS settings of the client, when we have a CA or pinned
+// fingerprints the certificate of the server is always verified, so the InsecureFlag
+// is ignored.
+// Inputs:
+// opts: (TLSOptions) The TLS settings that we want.
+func WithTLS(opts TLSOptions) Option {
+	return func(c *HTTPClient) error {
+		for _, pin := range opts.PinnedSHA256 {
+			_, err := decodeFingerprint(pin)
+			if err != nil {
+				return err
+			}
+		}
+		if (opts.ClientCertFile == "") != (opts.ClientKeyFile == "") {
+			return errors.New("the client certificate needs both files, the certificate and the key")
+		}
+		c.tlsOptions = &opts
+		return nil
+	}
+}
+
+// verifies method return true when the options say how to verify the server.
+func (o *TLSOptions) verifies() bool {
+	return o != nil && (o.CAFile != "" || len(o.CAPEM) > 0 || len(o.PinnedSHA256) > 0)
+}
+
+// tlsConfig method build the TLS settings of the client over base.
+// Inputs:
+// base: (*tls.Config) The settings that we already have, it can be nil.
+// Outputs:
+// (*tls.Config) The new settings,
+// (error) If we can't load the certificates.
+func (c *HTTPClient) tlsConfig(base *tls.Config) (*tls.Config, error) {
+	config := base.Clone()
+	if config == nil {
+		config = new(tls.Config)
+	}
+	opts := c.tlsOptions
+	config.InsecureSkipVerify = c.InsecureFlag
+	if opts == nil {
+		return config, nil
+	}
+	if opts.ServerName != "" {
+		config.ServerName = opts.ServerName
+	}
+	config.MinVersion = tls.VersionTLS12
+	if opts.MinVersion != 0 {
+		config.MinVersion = opts.MinVersion
+	}
+	if opts.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading the client certificate %q: %w", opts.ClientCertFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if !opts.verifies() {
+		return config, nil
+	}
+	if c.InsecureFlag {
+		c.Log().Info().Msg("We have TLS verification settings, so we ignore the Insecure flag.")
+	}
+	config.InsecureSkipVerify = false
+	if opts.CAFile != "" || len(opts.CAPEM) > 0 {
+		pool, err := opts.certPool()
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if len(opts.PinnedSHA256) > 0 {
+		// The pinning replace the normal verification, but if we have CAs
+		// we verify the chain as well in the VerifyConnection function.
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = opts.verifyConnection(config.RootCAs)
+	}
+	return config, nil
+}
+
+// certPool method read the CAs of the options in a new pool.
+func (o *TLSOptions) certPool() (*x509.CertPool, error) {
+	data := bytes.Clone(o.CAPEM)
+	if o.CAFile != "" {
+		file, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading the CA file %q: %w", o.CAFile, err)
+		}
+		data = append(data, '\n')
+		data = append(data, file...)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("the CA %q doesn't have any PEM certificate", o.CAFile)
+	}
+	return pool, nil
+}
+
+// verifyConnection method return the function that check the pinned fingerprints
+// and the chain of the server, if we have CAs in roots.
+func (o *TLSOptions) verifyConnection(roots *x509.CertPool) func(tls.ConnectionState) error {
+	pins := make(map[string]bool, len(o.PinnedSHA256))
+	for _, pin := range o.PinnedSHA256 {
+		fingerprint, _ := decodeFingerprint(pin)
+		pins[string(fingerprint)] = true
+	}
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("%w: the server hasn't sent any certificate", ErrCertificatePin)
+		}
+		if roots != nil {
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+				DNSName:       cs.ServerName,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, cert := range cs.PeerCertificates {
+			fingerprint := sha256.Sum256(cert.Raw)
+			if pins[string(fingerprint[:])] {
+				return nil
+			}
+		}
+		leaf := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		return fmt.Errorf("%w: the server sent %s", ErrCertificatePin, hex.EncodeToString(leaf[:]))
+	}
+}
+
+// decodeFingerprint function convert the fingerprint in hex, with or without colons, in bytes.
+func decodeFingerprint(f string) ([]byte, error) {
+	clean := strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(f))
+	fingerprint, err := hex.DecodeString(clean)
+	if err != nil || len(fingerprint) != sha256.Size {
+		return nil, fmt.Errorf("the fingerprint %q isn't a SHA-256 in hex", f)
+	}
+	return fingerprint, nil
+}
+
+// isTLSError function return true when the error happened verifying the certificate of the server.
+func isTLSError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var verification *tls.CertificateVerificationError
+	return errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &verification) ||
+		errors.Is(err, ErrCertificatePin)
+}
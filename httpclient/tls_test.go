@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTLSTestServer(t *testing.T) (*httptest.Server, string, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	cert := server.Certificate()
+	certFile := filepath.Join(t.TempDir(), "workstationapi-cert.pem")
+	err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0600)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	fingerprint := sha256.Sum256(cert.Raw)
+	return server, certFile, hex.EncodeToString(fingerprint[:])
+}
+
+func TestWithTLS(t *testing.T) {
+	server, certFile, fingerprint := newTLSTestServer(t)
+	colons := strings.ToUpper(fingerprint[:2]) + ":" + strings.ToUpper(fingerprint[2:])
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr error
+	}{
+		{"ca file", TLSOptions{CAFile: certFile}, nil},
+		{"pinned", TLSOptions{PinnedSHA256: []string{colons}}, nil},
+		{"ca and pinned", TLSOptions{CAFile: certFile, PinnedSHA256: []string{fingerprint}}, nil},
+		{"wrong pin", TLSOptions{PinnedSHA256: []string{strings.Repeat("00", sha256.Size)}}, ErrCertificatePin},
+		{"wrong server name", TLSOptions{CAFile: certFile, ServerName: "vmrest.invalid"}, ErrTLSVerification},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The Insecure flag must be ignored when we have verification settings
+			apiClient, err := NewClient(server.URL+"/api", "user", "pass", true, "NONE", WithTLS(tt.opts))
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			_, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("The call should be successful: %v", err)
+			}
+			if tt.wantErr != nil && (!errors.Is(err, tt.wantErr) || !errors.Is(err, ErrTLSVerification)) {
+				t.Errorf("The error should be %v: %v", tt.wantErr, err)
+			}
+			if errors.Is(err, ErrServerUnavailable) {
+				t.Errorf("A TLS error isn't a transient error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSVerificationWithoutOptions(t *testing.T) {
+	server, _, _ := newTLSTestServer(t)
+	apiClient, err := NewClient(server.URL+"/api", "user", "pass", false, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, err = apiClient.ApiCall("vms", "GET", bytes.Buffer{})
+	if !errors.Is(err, ErrTLSVerification) {
+		t.Errorf("The error should be ErrTLSVerification: %v", err)
+	}
+}
+
+func TestWithTLSInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts TLSOptions
+	}{
+		{"bad fingerprint", TLSOptions{PinnedSHA256: []string{"abcd"}}},
+		{"missing ca file", TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{"cert without key", TLSOptions{ClientCertFile: "client.pem"}},
+		{"missing client cert", TLSOptions{ClientCertFile: "missing.pem", ClientKeyFile: "missing-key.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient("https://localhost:8697/api", "user", "pass", false, "NONE", WithTLS(tt.opts))
+			if err == nil {
+				t.Errorf("The options should be rejected: %#v", tt.opts)
+			}
+		})
+	}
+}
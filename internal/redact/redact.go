@@ -0,0 +1,230 @@
+// Package redact mask the secrets, like the passwords or the values of the sensitive
+// keys, before the packages of the module write them in the logs.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Value is the text that we write in the logs instead of a secret.
+const Value = "******"
+
+// defaultSensitiveKeys are the keys whose values never appear in the logs, we
+// compare them without case and with the syntax of path.Match.
+var defaultSensitiveKeys = []string{
+	"authorization",
+	"proxy-authorization",
+	"password",
+	"passwd",
+	"passphrase",
+	"secret",
+	"token",
+	"guestinfo.*",
+}
+
+// keyValueLine match the lines like `key = "value"` of the vmx files and `key: value` of config.ini.
+var keyValueLine = regexp.MustCompile(`(?m)^(\s*)([A-Za-z0-9_.\-]+)(\s*[=:]\s*)(.*?)(\r?)$`)
+
+// Redactor object, this object mask the secrets before we write them in the logs.
+// The secrets can be literal values, like the password of the client, or the
+// values of the sensitive keys, like the Authorization header or the guestinfo.*
+// parameters of the vmx files.
+type Redactor struct {
+	mu      sync.RWMutex
+	keys    []string
+	secrets map[string]bool
+	secret  string
+}
+
+// Default is the Redactor of the String function, that we use out of the clients.
+// Each client has its own Redactor, so their passwords never end here.
+var Default = New()
+
+// New constructor of the Redactor object with the default sensitive keys
+// and the extra keys that we want.
+// Inputs:
+// keys: (...string) Extra sensitive keys, like "guestinfo.*" or "annotation".
+func New(keys ...string) *Redactor {
+	r := &Redactor{secrets: make(map[string]bool)}
+	r.AddSensitiveKeys(defaultSensitiveKeys...)
+	r.AddSensitiveKeys(keys...)
+	return r
+}
+
+// AddSensitiveKeys method add the keys whose values we have to mask.
+func (r *Redactor) AddSensitiveKeys(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key != "" {
+			r.keys = append(r.keys, key)
+		}
+	}
+}
+
+// AddSecret method register a literal value that we have to mask wherever it appears.
+func (r *Redactor) AddSecret(s string) {
+	if s == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets[s] = true
+}
+
+// SetSecret method change the value that we mask for the owner of the Redactor, like the
+// password of a client, the previous one isn't a secret anymore when it changes.
+func (r *Redactor) SetSecret(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secret = s
+}
+
+// IsSensitiveKey method return true if the values of the key k have to be masked.
+func (r *Redactor) IsSensitiveKey(k string) bool {
+	k = strings.ToLower(strings.TrimSpace(k))
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, pattern := range r.keys {
+		if pattern == k {
+			return true
+		}
+		if ok, _ := path.Match(pattern, k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String method return s with all the secrets masked, s can be a JSON document,
+// the lines of a vmx or config.ini file, or any other text.
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+	if redacted, ok := r.json(s); ok {
+		s = redacted
+	} else {
+		s = keyValueLine.ReplaceAllStringFunc(s, func(line string) string {
+			m := keyValueLine.FindStringSubmatch(line)
+			if !r.IsSensitiveKey(m[2]) {
+				return line
+			}
+			return m[1] + m[2] + m[3] + Value + m[5]
+		})
+	}
+	return r.secretValues(s)
+}
+
+// Header method return a copy of the headers h with the sensitive ones masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := h.Clone()
+	for key := range redacted {
+		if r.IsSensitiveKey(key) {
+			redacted[key] = []string{Value}
+			continue
+		}
+		for i, value := range redacted[key] {
+			redacted[key][i] = r.secretValues(value)
+		}
+	}
+	return redacted
+}
+
+// Writer method return a writer that mask the secrets before write in w, so we can
+// use it as output of the loggers to protect all the lines, not only ours.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return &redactWriter{redactor: r, out: w}
+}
+
+// json method mask the values of the sensitive keys if s is a JSON document, and the
+// "value" of the objects whose "name" is sensitive, like the ParamPayload of vmrest.
+func (r *Redactor) json(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return s, false
+	}
+	var document any
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	if decoder.Decode(&document) != nil {
+		return s, false
+	}
+	data, err := json.Marshal(r.walk(document))
+	if err != nil {
+		return s, false
+	}
+	return string(data), true
+}
+
+// walk method mask the sensitive values in the JSON document v.
+func (r *Redactor) walk(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		name, _ := value["name"].(string)
+		sensitiveName := name != "" && r.IsSensitiveKey(name)
+		for key, item := range value {
+			if r.IsSensitiveKey(key) || (sensitiveName && strings.EqualFold(key, "value")) {
+				value[key] = Value
+				continue
+			}
+			value[key] = r.walk(item)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = r.walk(item)
+		}
+		return value
+	}
+	return v
+}
+
+// secretValues method replace the literal secrets in s.
+func (r *Redactor) secretValues(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Value)
+	}
+	if r.secret != "" {
+		s = strings.ReplaceAll(s, r.secret, Value)
+	}
+	return s
+}
+
+// redactWriter is the writer that Redactor.Writer give us.
+type redactWriter struct {
+	redactor *Redactor
+	out      io.Writer
+}
+
+// Write method to implement the io.Writer interface, we report the length of p
+// because the callers don't know that we have changed the content.
+func (w *redactWriter) Write(p []byte) (int, error) {
+	w.redactor.mu.RLock()
+	clean := len(w.redactor.secrets) == 0 && w.redactor.secret == ""
+	w.redactor.mu.RUnlock()
+	if clean {
+		return w.out.Write(p)
+	}
+	redacted := []byte(w.redactor.secretValues(string(p)))
+	if bytes.Equal(redacted, p) {
+		return w.out.Write(p)
+	}
+	_, err := w.out.Write(redacted)
+	return len(p), err
+}
+
+// String function mask the secrets of s with the Default Redactor.
+func String(s string) string {
+	return Default.String(s)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/TwiN/go-color"
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
 	"github.com/elsudano/vmware-workstation-api-client/wsapiclient"
 	"github.com/elsudano/vmware-workstation-api-client/wsapivm"
 	"github.com/rs/zerolog/log"
@@ -35,6 +37,15 @@ func PrintVM(VM *wsapivm.MyVm) {
 		color.Ize(title_line_color, "DomainName:"), color.Ize(value_color, VM.DNS.Domainname), "\n",
 		color.Ize(title_line_color, "DNServers:"), color.Ize(value_color, VM.DNS.Servers),
 	)
+	if VM.IP != "" {
+		fmt.Println(
+			color.Ize(title_line_color, " IP:"), color.Ize(value_color, VM.IP), "\n",
+			color.Ize(title_line_color, "DNSearch:"), color.Ize(value_color, VM.DNS.Search), "\n",
+			color.Ize(title_line_color, "WINS:"), color.Ize(value_color, VM.WINS.Primary), color.Ize(value_color, VM.WINS.Secondary), "\n",
+			color.Ize(title_line_color, "DHCPv4:"), color.Ize(value_color, VM.DHCPv4.Enabled), "\n",
+			color.Ize(title_line_color, "DHCPv6:"), color.Ize(value_color, VM.DHCPv6.Enabled),
+		)
+	}
 	if VM.NICS != nil {
 		for nic := range VM.NICS {
 			fmt.Println(
@@ -43,6 +54,11 @@ func PrintVM(VM *wsapivm.MyVm) {
 			)
 		}
 	}
+	for _, route := range VM.Routes {
+		fmt.Println(
+			color.Ize(title_line_color, " Route:"), color.Ize(value_color, fmt.Sprintf("%s/%d via %s", route.Dest, route.Prefix, route.Nexthop)),
+		)
+	}
 }
 
 func main() {
@@ -65,17 +81,21 @@ func main() {
 	}
 	scanner := bufio.NewScanner(file)
 	scanner.Split(bufio.ScanLines)
-	var varuser, varpass, varurl, varparentid, vardebug string
+	var varuser, varpass, varurl, varparentid, vardebug, varcafile, varpassfile string
 	var varinsecure bool
 	for scanner.Scan() {
 		array := strings.SplitN(scanner.Text(), ":", 2)
 		key := strings.ToLower(array[0])
 		value := array[1]
 		if !strings.HasPrefix(key, "#") {
+			printable := value
+			if httpclient.DefaultRedactor.IsSensitiveKey(key) {
+				printable = httpclient.RedactedValue
+			}
 			fmt.Println(
-				color.Ize(title_line_color, "Temp: "), color.Ize(value_color, array),
+				color.Ize(title_line_color, "Temp: "), color.Ize(value_color, []string{array[0], printable}),
 				color.Ize(title_line_color, "Key: "), color.Ize(value_color, key),
-				color.Ize(title_line_color, "Value: "), color.Ize(value_color, value),
+				color.Ize(title_line_color, "Value: "), color.Ize(value_color, printable),
 			)
 			switch key {
 			case "user":
@@ -94,6 +114,10 @@ func main() {
 				}
 			case "debug":
 				vardebug = strings.TrimSpace(value)
+			case "cafile":
+				varcafile = strings.TrimSpace(value)
+			case "passwordfile":
+				varpassfile = strings.TrimSpace(value)
 			}
 		}
 	}
@@ -103,7 +127,7 @@ func main() {
 	fmt.Println(color.Ize(paragraph_color, "First of all we want to see which are the Input Values:"))
 	fmt.Println(
 		color.Ize(title_line_color, "varuser:"), color.Ize(value_color, varuser),
-		color.Ize(title_line_color, "varpass:"), color.Ize(value_color, varpass),
+		color.Ize(title_line_color, "varpass:"), color.Ize(value_color, httpclient.RedactedValue),
 		color.Ize(title_line_color, "varurl:"), color.Ize(value_color, varurl),
 		color.Ize(title_line_color, "varparentid:"), color.Ize(value_color, varparentid),
 		color.Ize(title_line_color, "varinsecure:"), color.Ize(value_color, varinsecure),
@@ -111,13 +135,28 @@ func main() {
 	)
 	fmt.Println()
 	file.Close()
-	client := wsapiclient.New()
+	var options []httpclient.Option
+	if varcafile != "" {
+		// With the certificate that generates `make api_start` we don't need the insecure mode
+		options = append(options, httpclient.WithTLS(httpclient.TLSOptions{CAFile: varcafile}))
+	}
+	// We prefer don't have the password in plain text inside of the config.ini
+	switch {
+	case varpassfile != "":
+		options = append(options, httpclient.WithCredentials(httpclient.FileCredentials{Path: varpassfile}))
+	case varpass == "":
+		options = append(options, httpclient.WithCredentials(httpclient.EnvCredentials{}))
+	}
+	client := wsapiclient.New(options...)
 	client.ConfigLog(vardebug, "HR")
+	defer client.Close()
 	err = client.ConfigApiClient(varurl, varuser, varpass, varinsecure, vardebug)
 	if err != nil {
 		log.Error().Err(err).Msgf("Creating client error %#v", err)
 		os.Exit(9)
 	}
+	// We want to see the addresses of the VMs that are running
+	client.SetGuestNetwork(true)
 	fmt.Println(color.Ize(paragraph_color, "We can see here the value of the ParentID VM:"))
 	fmt.Println(color.Ize(title_line_color, "Parent ID:"), color.Ize(value_color, varparentid))
 
@@ -164,13 +203,18 @@ func main() {
 		log.Error().Err(err).Msgf("Updating VM Error %#v", err)
 		os.Exit(15)
 	}
-	time.Sleep(60 * time.Second) // we need to wait because the VM take time to be ready
-	// fmt.Println(color.Ize(paragraph_color, "We can confirm that the VM is working properly:"))
-	// VM, err = client.LoadVM(VM.IdVM)
-	// if err != nil {
-	//	log.Error().Err(err).Msgf("Second time Reading VM Error %#v", err)
-	// 	os.Exit(16)
-	// }
+	// we need to wait because the VM take time to be ready
+	_, err = client.WaitForIP(context.Background(), VM, wsapivm.WaitOptions{Stable: 3, Timeout: 5 * time.Minute})
+	if err != nil {
+		log.Error().Err(err).Msgf("Waiting the IP of the VM Error %#v", err)
+		os.Exit(16)
+	}
+	fmt.Println(color.Ize(paragraph_color, "We can confirm that the VM is working properly:"))
+	VM, err = client.LoadVM(VM.IdVM)
+	if err != nil {
+		log.Error().Err(err).Msgf("Second time Reading VM Error %#v", err)
+		os.Exit(16)
+	}
 	PrintVM(VM)
 	// We want to shutdown the instance in order to test the UpdateVM method
 	fmt.Println(color.Ize(paragraph_color, "Now, we going to shutdown and change the propierties of the VM with the UpdateVM method"))
@@ -179,7 +223,11 @@ func main() {
 		log.Error().Err(err).Msgf("Updating VM Error %#v", err)
 		os.Exit(17)
 	}
-	time.Sleep(30 * time.Second)
+	err = client.WaitForPowerState(context.Background(), VM, wsapivm.PowerStateOff, wsapivm.WaitOptions{Timeout: 2 * time.Minute})
+	if err != nil {
+		log.Error().Err(err).Msgf("Waiting the shutdown of the VM Error %#v", err)
+		os.Exit(18)
+	}
 	// fmt.Println(color.Ize(paragraph_color, "We confirm that the VM is off and it's propierties has changed:"))
 	// VM, err = client.LoadVM(VM.IdVM)
 	// if err != nil {
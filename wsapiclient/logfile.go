@@ -0,0 +1,266 @@
+package wsapiclient
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLogFile = "debug.log"
+	// backupTimeFormat is the format of the timestamp that we put in the name of the
+	// rotated files, in this way the lexical order is the same that the time order.
+	backupTimeFormat = "20060102T150405.000000000"
+	// rotateRetry is the time that we wait to rotate the file again after a rotation
+	// that couldn't move it, otherwise we would try it (and fail) in each write.
+	rotateRetry = time.Minute
+)
+
+// LogFileOptions are the settings of the file where we write the logs.
+// Path: (string) Path of the log file, by default debug.log in the working directory.
+// MaxSize: (int64) Size in bytes that the file can reach before we rotate it, 0 means no limit.
+// MaxAge: (time.Duration) Time that we write in the same file before we rotate it, 0 means no limit.
+// MaxBackups: (int) Number of rotated files that we keep, 0 means that we keep all of them.
+// Compress: (bool) True if we want to compress the rotated files with gzip.
+// Format: (string) JSON to write the events as they are, HR (by default) to write them Human Readable.
+type LogFileOptions struct {
+	Path       string
+	MaxSize    int64
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
+	Format     string
+}
+
+// LogFile is an io.WriteCloser that write in a file and rotate it when it's too
+// big or too old, it's safe to use it from different goroutines.
+type LogFile struct {
+	opts   LogFileOptions
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	retry  time.Time
+}
+
+// NewLogFile function open (or create) the log file with the options that we want.
+// Inputs:
+// opts: (LogFileOptions) The settings of the file.
+// Outputs:
+// (*LogFile) The file ready to write on it.
+// error: (error) If we can't open the file or the options aren't valid.
+func NewLogFile(opts LogFileOptions) (*LogFile, error) {
+	if opts.Path == "" {
+		opts.Path = defaultLogFile
+	}
+	if opts.MaxSize < 0 || opts.MaxAge < 0 || opts.MaxBackups < 0 {
+		return nil, errors.New("the limits of the log file can't be negative")
+	}
+	opts.Format = strings.ToUpper(opts.Format)
+	switch opts.Format {
+	case "":
+		opts.Format = "HR"
+	case "HR", "JSON":
+	default:
+		return nil, fmt.Errorf("the format %q of the log file isn't valid, choose between JSON or HR", opts.Format)
+	}
+	lf := &LogFile{opts: opts}
+	err := lf.open()
+	if err != nil {
+		return nil, err
+	}
+	return lf, nil
+}
+
+// Write method to implement the io.Writer interface, before write we rotate the
+// file if the new data exceed the MaxSize or the file is older than MaxAge. If the
+// rotation fails we write the data in the file that we have open and we return the
+// error of the rotation, we don't try to rotate it again until rotateRetry has passed.
+func (lf *LogFile) Write(p []byte) (int, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.file == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if lf.mustRotate(int64(len(p))) {
+		rotateErr = lf.rotate()
+		if lf.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := lf.file.Write(p)
+	lf.size += int64(n)
+	return n, errors.Join(rotateErr, err)
+}
+
+// Rotate method close the current file, move it to a backup and open a new one.
+// Outputs:
+// error: (error) If we can't rotate the file.
+func (lf *LogFile) Rotate() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.file == nil {
+		return os.ErrClosed
+	}
+	return lf.rotate()
+}
+
+// Close method close the file, after that all the writes fail.
+// Outputs:
+// error: (error) If we can't close the file.
+func (lf *LogFile) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.file == nil {
+		return nil
+	}
+	err := lf.file.Close()
+	lf.file = nil
+	return err
+}
+
+// Format method return the format that we have chosen for the file, JSON or HR.
+func (lf *LogFile) Format() string {
+	return lf.opts.Format
+}
+
+// mustRotate method return true when we have to rotate the file before write n bytes.
+func (lf *LogFile) mustRotate(n int64) bool {
+	if lf.size == 0 || time.Now().Before(lf.retry) {
+		return false
+	}
+	if lf.opts.MaxSize > 0 && lf.size+n > lf.opts.MaxSize {
+		return true
+	}
+	return lf.opts.MaxAge > 0 && time.Since(lf.opened) >= lf.opts.MaxAge
+}
+
+// open method open the file in append mode, it creates the folder if it's needed.
+func (lf *LogFile) open() error {
+	err := os.MkdirAll(filepath.Dir(lf.opts.Path), 0755)
+	if err != nil {
+		return fmt.Errorf("creating the folder of the log file: %w", err)
+	}
+	file, err := os.OpenFile(lf.opts.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0664)
+	if err != nil {
+		return fmt.Errorf("opening the log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("reading the size of the log file: %w", err)
+	}
+	lf.file = file
+	lf.size = info.Size()
+	lf.opened = time.Now()
+	return nil
+}
+
+// rotate method do the rotation, the caller must hold the lock. Whatever fails we
+// open the file again, in the worst case we keep writing in the same file and we
+// don't try to rotate it again until rotateRetry has passed.
+func (lf *LogFile) rotate() error {
+	err := lf.file.Close()
+	lf.file = nil
+	if err != nil {
+		lf.retry = time.Now().Add(rotateRetry)
+		return errors.Join(fmt.Errorf("closing the log file: %w", err), lf.open())
+	}
+	backup := lf.backupName(time.Now())
+	err = os.Rename(lf.opts.Path, backup)
+	if err != nil {
+		lf.retry = time.Now().Add(rotateRetry)
+		return errors.Join(fmt.Errorf("moving the log file to %s: %w", backup, err), lf.open())
+	}
+	lf.retry = time.Time{}
+	var compressErr error
+	if lf.opts.Compress {
+		compressErr = compressFile(backup)
+	}
+	err = lf.open()
+	if err != nil {
+		return errors.Join(compressErr, err)
+	}
+	return errors.Join(compressErr, lf.prune())
+}
+
+// backupName method return the name of the rotated file, like debug-20240102T150405.000000000.log
+func (lf *LogFile) backupName(t time.Time) string {
+	ext := filepath.Ext(lf.opts.Path)
+	prefix := strings.TrimSuffix(lf.opts.Path, ext)
+	return prefix + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// backups method return the rotated files that we have, from the oldest to the newest.
+func (lf *LogFile) backups() ([]string, error) {
+	ext := filepath.Ext(lf.opts.Path)
+	prefix := strings.TrimSuffix(lf.opts.Path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(match, prefix), ".gz"), ext)
+		_, err = time.Parse(backupTimeFormat, stamp)
+		if err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// prune method remove the oldest rotated files when we have more than MaxBackups.
+func (lf *LogFile) prune() error {
+	if lf.opts.MaxBackups == 0 {
+		return nil
+	}
+	backups, err := lf.backups()
+	if err != nil {
+		return fmt.Errorf("listing the rotated log files: %w", err)
+	}
+	for len(backups) > lf.opts.MaxBackups {
+		err = os.Remove(backups[0])
+		if err != nil {
+			return fmt.Errorf("removing the rotated log file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// compressFile Auxiliary function to compress f in f.gz and remove f.
+func compressFile(f string) error {
+	in, err := os.Open(f)
+	if err != nil {
+		return fmt.Errorf("opening the rotated log file: %w", err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(f+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return fmt.Errorf("creating the compressed log file: %w", err)
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f + ".gz")
+		return fmt.Errorf("compressing the rotated log file: %w", err)
+	}
+	in.Close()
+	return os.Remove(f)
+}
@@ -0,0 +1,178 @@
+package wsapiclient
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func TestLogFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "wsapi.log")
+	file, err := NewLogFile(LogFileOptions{Path: path, MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = file.Write([]byte(line))
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "fourth\n" {
+		t.Errorf("The current file should have just the last line, we have: %q", current)
+	}
+	backups, err := file.backups()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("We should keep 2 rotated files, we have: %#v", backups)
+	}
+	for pos, want := range []string{"second\n", "third\n"} {
+		if !strings.HasSuffix(backups[pos], ".log.gz") {
+			t.Errorf("The rotated file %s isn't compressed", backups[pos])
+		}
+		gzfile, err := os.Open(backups[pos])
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		gz, err := gzip.NewReader(gzfile)
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		content, _ := io.ReadAll(gz)
+		gzfile.Close()
+		if string(content) != want {
+			t.Errorf("The rotated file %s should contain %q, we have %q", backups[pos], want, content)
+		}
+	}
+	_, err = file.Write([]byte("closed\n"))
+	if err == nil {
+		t.Errorf("We shouldn't write in a closed file")
+	}
+}
+
+func TestLogFileMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wsapi.log")
+	file, err := NewLogFile(LogFileOptions{Path: path, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	defer file.Close()
+	file.Write([]byte("old\n"))
+	file.opened = file.opened.Add(-2 * time.Hour)
+	file.Write([]byte("new\n"))
+	backups, _ := file.backups()
+	if len(backups) != 1 {
+		t.Errorf("The old file should be rotated, we have: %#v", backups)
+	}
+}
+
+func TestNewLogFileOptions(t *testing.T) {
+	_, err := NewLogFile(LogFileOptions{Path: filepath.Join(t.TempDir(), "wsapi.log"), Format: "XML"})
+	if err == nil {
+		t.Errorf("The format XML shouldn't be valid")
+	}
+	_, err = NewLogFile(LogFileOptions{Path: filepath.Join(t.TempDir(), "wsapi.log"), MaxSize: -1})
+	if err == nil {
+		t.Errorf("A negative size shouldn't be valid")
+	}
+}
+
+func TestConfigLogFile(t *testing.T) {
+	previous := log.Logger
+	defer func() { log.Logger = previous }()
+	path := filepath.Join(t.TempDir(), "wsapi.log")
+	client := New()
+	err := client.ConfigLogFile("INFO", LogFileOptions{Path: path, Format: "json"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	client.(*WSAPIClient).Caller.Log().Info().Msg("message after configure")
+	err = client.Close()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), `"m":"message after configure"`) {
+		t.Errorf("The log file doesn't contain the message: %q", content)
+	}
+	err = client.Close()
+	if err != nil {
+		t.Errorf("Close twice shouldn't fail: %#v", err)
+	}
+}
+
+func TestLogFileRotateFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoil func(t *testing.T, path string)
+	}{
+		{"read-only folder", func(t *testing.T, path string) {
+			if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+				t.Skip("The permissions of the folder don't stop this user")
+			}
+			dir := filepath.Dir(path)
+			err := os.Chmod(dir, 0555)
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			t.Cleanup(func() { os.Chmod(dir, 0755) })
+		}},
+		{"removed file", func(t *testing.T, path string) {
+			err := os.Remove(path)
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "wsapi.log")
+			file, err := NewLogFile(LogFileOptions{Path: path, MaxSize: 10})
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			defer file.Close()
+			_, err = file.Write([]byte("first\n"))
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			test.spoil(t, path)
+			n, err := file.Write([]byte("second\n"))
+			if err == nil || n != len("second\n") {
+				t.Errorf("We should write the line and return the error of the rotation: %d %#v", n, err)
+			}
+			// The file is still too big, but we shouldn't try to rotate it again so soon.
+			file.size = 100
+			_, err = file.Write([]byte("third\n"))
+			if errors.Is(err, os.ErrClosed) {
+				t.Fatalf("The log file shouldn't be closed after a failed rotation: %#v", err)
+			}
+			if err != nil {
+				t.Errorf("The write after a failed rotation shouldn't retry it: %#v", err)
+			}
+			backups, _ := file.backups()
+			if len(backups) != 0 {
+				t.Errorf("We shouldn't rotate until rotateRetry has passed: %v", backups)
+			}
+			content, _ := os.ReadFile(path)
+			if !strings.Contains(string(content), "second\n") && !strings.Contains(string(content), "third\n") {
+				t.Errorf("We should keep writing in the log file, we have: %q", content)
+			}
+		})
+	}
+}
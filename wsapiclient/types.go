@@ -1,22 +1,62 @@
 package wsapiclient
 
 import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
 	"github.com/elsudano/vmware-workstation-api-client/wsapinet"
 	"github.com/elsudano/vmware-workstation-api-client/wsapivm"
+	"github.com/rs/zerolog"
 )
 
 // Interface with all the methods that we can use to talk with the API of VmWare Workstation Pro
 type WSAPIService interface {
 	ConfigLog(lvl string, mode string)
+	ConfigLogFile(lvl string, opts LogFileOptions) error
+	SetLogger(l *zerolog.Logger)
+	Close() error
 	ConfigApiClient(a string, u string, p string, i bool, d string) error
 	GetAllVMs() ([]wsapivm.MyVm, error)
 	LoadVM(i string) (*wsapivm.MyVm, error)
 	LoadVMbyName(n string) (*wsapivm.MyVm, error)
+	LoadVMbyPath(p string) (*wsapivm.MyVm, error)
 	CreateVM(pid string, n string, d string, p int32, m int32, s string) (*wsapivm.MyVm, error)
 	UpdateVM(vm *wsapivm.MyVm, n string, d string, p int32, m int32, s string) error
 	RegisterVM(vm *wsapivm.MyVm) error
 	DeleteVM(vm *wsapivm.MyVm) error
+	GetAllVMsContext(ctx context.Context) ([]wsapivm.MyVm, error)
+	LoadVMContext(ctx context.Context, i string) (*wsapivm.MyVm, error)
+	LoadVMbyNameContext(ctx context.Context, n string) (*wsapivm.MyVm, error)
+	LoadVMbyPathContext(ctx context.Context, p string) (*wsapivm.MyVm, error)
+	CreateVMContext(ctx context.Context, pid string, n string, d string, p int32, m int32, s string) (*wsapivm.MyVm, error)
+	Create(spec wsapivm.CreateVMSpec) (*wsapivm.MyVm, error)
+	CreateContext(ctx context.Context, spec wsapivm.CreateVMSpec) (*wsapivm.MyVm, error)
+	UpdateVMContext(ctx context.Context, vm *wsapivm.MyVm, n string, d string, p int32, m int32, s string) error
+	Update(vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.UpdateReport, error)
+	UpdateContext(ctx context.Context, vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.UpdateReport, error)
+	PlanUpdate(vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.Plan, error)
+	PlanUpdateContext(ctx context.Context, vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.Plan, error)
+	ApplyPlan(vm *wsapivm.MyVm, plan *wsapivm.Plan) (*wsapivm.UpdateReport, error)
+	ApplyPlanContext(ctx context.Context, vm *wsapivm.MyVm, plan *wsapivm.Plan) (*wsapivm.UpdateReport, error)
+	RegisterVMContext(ctx context.Context, vm *wsapivm.MyVm) error
+	DeleteVMContext(ctx context.Context, vm *wsapivm.MyVm) error
+	StopVM(vm *wsapivm.MyVm) error
+	StopVMContext(ctx context.Context, vm *wsapivm.MyVm) error
+	SetStopPolicy(p wsapivm.StopPolicy)
+	WaitForPowerState(ctx context.Context, vm *wsapivm.MyVm, state wsapivm.PowerState, opts ...wsapivm.WaitOptions) error
+	WaitForIP(ctx context.Context, vm *wsapivm.MyVm, opts ...wsapivm.WaitOptions) (string, error)
+	SetGuestNetwork(enabled bool)
+	SetFields(f wsapivm.VMField)
+	SetConcurrency(n int)
+	SetInventoryCache(ttl time.Duration)
+	SetRollback(enabled bool)
+	SetMetadataStrategy(s wsapivm.MetadataStrategy)
+	SetRequiredMetadata(required bool)
+	Refresh() error
+	RefreshContext(ctx context.Context) error
 }
 
 // That's the abstract object that we will use to interact with API of VmWare Workstation Pro
@@ -24,4 +64,6 @@ type WSAPIClient struct {
 	Caller     *httpclient.HTTPClient
 	VMService  wsapivm.VMService
 	NETService wsapinet.NETService
+	mu         sync.Mutex
+	logFile    io.Closer
 }
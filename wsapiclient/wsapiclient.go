@@ -1,7 +1,9 @@
 package wsapiclient
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -20,8 +22,9 @@ const (
 )
 
 // New functon is just to create a new object APIClient to make the different calls at VmWare Workstation Pro
-func New() WSAPIService {
-	myclient, err := httpclient.New()
+// opts: (...httpclient.Option) Optional settings of the HTTP client, like middlewares.
+func New(opts ...httpclient.Option) WSAPIService {
+	myclient, err := httpclient.New(opts...)
 	if err != nil {
 		log.Error().Err(err).Msg("We can't create the Client.")
 		return nil
@@ -33,66 +36,30 @@ func New() WSAPIService {
 	}
 }
 
-// ConfigLog method change the behavior that how to handle the logging on our API
+// ConfigLog method change the behavior that how to handle the logging on our API,
+// it's a convenience for the CLI, because it also changes the global logger of zerolog,
+// if you embed the library in another program it's better to use WithLogger or SetLogger.
 // Inputs:
 // lvl: (strings) Which will be the level bu default that we want in console
 // mode: (string) The format we want:
 // (defaut) JSON: in stderr,
-// FILE json format in debug.log file,
+// FILE human readable format in debug.log file, use ConfigLogFile to choose the path and the rotation,
 // CONSOLE stdout with color in json format,
 // ERROR stderr in json format
 // HR (Human Readable) in stdout
 func (wsapi *WSAPIClient) ConfigLog(lvl string, mode string) {
-	lvl = strings.ToUpper(lvl)
-	switch lvl {
-	case "DEBUG":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	case "INFO":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	case "ERROR":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
-	default:
-		zerolog.SetGlobalLevel(zerolog.Disabled)
-	}
-	// Global Settings https://github.com/rs/zerolog?tab=readme-ov-file#global-settings
-	zerolog.TimeFieldFormat = time.RFC3339 // zerolog.TimeFormatUnix zerolog.TimeFormatUnixMs, zerolog.TimeFormatUnixMicro
-
-	// Customized Fields Name https://github.com/rs/zerolog?tab=readme-ov-file#customize-automatic-field-names
-	zerolog.TimestampFieldName = "t"
-	zerolog.LevelFieldName = "l"
-	zerolog.MessageFieldName = "m"
-
-	// To trace the errors https://github.com/rs/zerolog?tab=readme-ov-file#add-file-and-line-number-to-log
-	zerolog.CallerMarshalFunc = func(pc uintptr, file string, line int) string {
-		return filepath.Base(file) + ":" + strconv.Itoa(line)
-	}
-	log.Logger = log.With().Caller().Logger()
-
+	redactor := wsapi.redactor()
 	// Formatting https://github.com/rs/zerolog?tab=readme-ov-file#pretty-logging
+	var output io.Writer
 	switch strings.ToUpper(mode) {
 	case "FILE":
-		file, err := os.OpenFile(
-			"debug.log",
-			os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-			0664,
-		)
+		err := wsapi.ConfigLogFile(lvl, LogFileOptions{})
 		if err != nil {
 			panic(err)
 		}
-		defer file.Close()
-		ConsoleWriter := zerolog.ConsoleWriter{Out: file, NoColor: false}
-		ConsoleWriter.FormatLevel = func(i interface{}) string {
-			return strings.ToUpper(fmt.Sprintf("| %-6s|", i))
-		}
-		ConsoleWriter.FormatMessage = func(i interface{}) string {
-			return fmt.Sprintf("%s", i)
-		}
-		ConsoleWriter.FormatFieldName = func(i interface{}) string {
-			return fmt.Sprintf("%s:", i)
-		}
-		log.Logger = log.With().Logger().Output(ConsoleWriter)
+		return
 	case "CONSOLE":
-		ConsoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, NoColor: true}
+		ConsoleWriter := zerolog.ConsoleWriter{Out: redactor.Writer(os.Stdout), NoColor: true}
 		ConsoleWriter.FormatLevel = func(i interface{}) string {
 			return strings.ToUpper(fmt.Sprintf("| %-6s|", i))
 		}
@@ -108,18 +75,18 @@ func (wsapi *WSAPIClient) ConfigLog(lvl string, mode string) {
 		ConsoleWriter.PartsExclude = []string{
 			zerolog.TimestampFieldName,
 		}
-		log.Logger = log.With().Logger().Output(ConsoleWriter)
+		output = ConsoleWriter
 	case "ERROR":
-		ConsoleWriter := zerolog.ConsoleWriter{Out: os.Stderr, NoColor: true}
+		ConsoleWriter := zerolog.ConsoleWriter{Out: redactor.Writer(os.Stderr), NoColor: true}
 		ConsoleWriter.FormatLevel = func(i interface{}) string {
 			return strings.ToUpper(fmt.Sprintf("[%-6s][WSAPICLI]", i))
 		}
 		ConsoleWriter.FormatMessage = func(i interface{}) string {
 			return fmt.Sprintf("%s", i)
 		}
-		log.Logger = log.With().Logger().Output(ConsoleWriter)
+		output = ConsoleWriter
 	case "HR":
-		ConsoleWriter := zerolog.ConsoleWriter{Out: os.Stdout, NoColor: true}
+		ConsoleWriter := zerolog.ConsoleWriter{Out: redactor.Writer(os.Stdout), NoColor: true}
 		ConsoleWriter.FormatLevel = func(i interface{}) string {
 			return strings.ToUpper(fmt.Sprintf("[%-6s][WSAPICLI]", i))
 		}
@@ -129,10 +96,128 @@ func (wsapi *WSAPIClient) ConfigLog(lvl string, mode string) {
 		ConsoleWriter.PartsExclude = []string{
 			zerolog.TimestampFieldName,
 		}
-		log.Logger = log.With().Logger().Output(ConsoleWriter)
+		output = ConsoleWriter
 	default:
-		log.Logger = log.With().Logger().Output(os.Stderr)
+		output = redactor.Writer(os.Stderr)
+	}
+	wsapi.setLogOutput(lvl, output, nil)
+}
+
+// ConfigLogFile method is like ConfigLog in FILE mode, but we can choose the path
+// of the file, when we rotate it, how many rotated files we keep and the format.
+// The client owns the file, so you have to call Close when you finish.
+// Inputs:
+// lvl: (strings) Which will be the level that we want in the file
+// opts: (LogFileOptions) The settings of the file.
+// Outputs:
+// error: (error) If we can't open the file or the options aren't valid.
+func (wsapi *WSAPIClient) ConfigLogFile(lvl string, opts LogFileOptions) error {
+	file, err := NewLogFile(opts)
+	if err != nil {
+		return err
+	}
+	var output io.Writer = wsapi.redactor().Writer(file)
+	if file.Format() == "HR" {
+		ConsoleWriter := zerolog.ConsoleWriter{Out: output, NoColor: true}
+		ConsoleWriter.FormatLevel = func(i interface{}) string {
+			return strings.ToUpper(fmt.Sprintf("| %-6s|", i))
+		}
+		ConsoleWriter.FormatMessage = func(i interface{}) string {
+			return fmt.Sprintf("%s", i)
+		}
+		ConsoleWriter.FormatFieldName = func(i interface{}) string {
+			return fmt.Sprintf("%s:", i)
+		}
+		output = ConsoleWriter
 	}
+	return wsapi.setLogOutput(lvl, output, file)
+}
+
+// Close method release the resources that the client owns, like the log file.
+// Outputs:
+// error: (error) If we can't close some resource.
+func (wsapi *WSAPIClient) Close() error {
+	wsapi.mu.Lock()
+	file := wsapi.logFile
+	wsapi.logFile = nil
+	wsapi.mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	return file.Close()
+}
+
+// setLogOutput method create the logger that write in output with the level lvl and
+// use it in the client, if the output is a file the client will close it in Close.
+// Inputs:
+// lvl: (strings) Which will be the level that we want.
+// output: (io.Writer) Where we write the logs.
+// file: (io.Closer) The file of the output, nil if we don't have to close it.
+// Outputs:
+// error: (error) If we can't close the previous log file.
+func (wsapi *WSAPIClient) setLogOutput(lvl string, output io.Writer, file io.Closer) error {
+	level := zerolog.Disabled
+	switch strings.ToUpper(lvl) {
+	case "DEBUG":
+		level = zerolog.DebugLevel
+	case "INFO":
+		level = zerolog.InfoLevel
+	case "ERROR":
+		level = zerolog.ErrorLevel
+	}
+	// Global Settings https://github.com/rs/zerolog?tab=readme-ov-file#global-settings
+	zerolog.TimeFieldFormat = time.RFC3339 // zerolog.TimeFormatUnix zerolog.TimeFormatUnixMs, zerolog.TimeFormatUnixMicro
+
+	// Customized Fields Name https://github.com/rs/zerolog?tab=readme-ov-file#customize-automatic-field-names
+	zerolog.TimestampFieldName = "t"
+	zerolog.LevelFieldName = "l"
+	zerolog.MessageFieldName = "m"
+
+	// To trace the errors https://github.com/rs/zerolog?tab=readme-ov-file#add-file-and-line-number-to-log
+	zerolog.CallerMarshalFunc = func(pc uintptr, file string, line int) string {
+		return filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+	logger := zerolog.New(output).Level(level).With().Timestamp().Caller().Logger()
+	wsapi.SetLogger(&logger)
+	// The CLI use the global logger, so we keep it with the same settings
+	log.Logger = logger
+	wsapi.mu.Lock()
+	previous := wsapi.logFile
+	wsapi.logFile = file
+	wsapi.mu.Unlock()
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
+}
+
+// redactor method return the Redactor that we use to mask the secrets in all the outputs.
+func (wsapi *WSAPIClient) redactor() *httpclient.Redactor {
+	if wsapi.Caller != nil && wsapi.Caller.Redactor != nil {
+		return wsapi.Caller.Redactor
+	}
+	return httpclient.DefaultRedactor
+}
+
+// SetLogger method change the logger of the HTTP client and of all the services,
+// in this way we don't need to touch the global logger of zerolog.
+// Inputs:
+// l: (*zerolog.Logger) The logger that we want to use, nil means the global logger.
+func (wsapi *WSAPIClient) SetLogger(l *zerolog.Logger) {
+	if wsapi.Caller != nil {
+		wsapi.Caller.SetLogger(l)
+	}
+	if wsapi.VMService != nil {
+		wsapi.VMService.SetLogger(l)
+	}
+	if wsapi.NETService != nil {
+		wsapi.NETService.SetLogger(l)
+	}
+}
+
+// log method return the logger that the client has to use.
+func (wsapi *WSAPIClient) log() *zerolog.Logger {
+	return wsapi.Caller.Log()
 }
 
 // ConfigApiClient method return a pointer of Client of API but now it's configure
@@ -152,62 +237,75 @@ func (wsapi *WSAPIClient) ConfigApiClient(a string, u string, p string, i bool,
 // []MyVm list of all VMs that we have in VmWare Workstation
 // (error) variable with the error if occur
 func (wsapi *WSAPIClient) GetAllVMs() ([]wsapivm.MyVm, error) {
-	return wsapi.VMService.GetAllVMs()
+	return wsapi.GetAllVMsContext(context.Background())
+}
+
+// GetAllVMsContext is the same as GetAllVMs but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) GetAllVMsContext(ctx context.Context) ([]wsapivm.MyVm, error) {
+	return wsapi.VMService.GetAllVMsContext(ctx)
 }
 
-// CreateVM method to create a new VM in VmWare Worstation
+// CreateVM method to create a new VM in VmWare Worstation, if something goes wrong after
+// the clone we delete it, unless we have disabled it with SetRollback.
 // Input:
 // pid: (string) with the ID of the Parent VM,
 // n: string with the denomination of the VM,
 // d: string with the description of VM
 // p: int with the number of processors in the VM
 // m: int with the number of memory in the VM
+// s: (string) this will be the state of the VM in creation (on, off)
 func (wsapi *WSAPIClient) CreateVM(pid string, n string, d string, p int32, m int32, s string) (*wsapivm.MyVm, error) {
-	vm, err := wsapi.VMService.CreateVM(pid, n, d, p, m, s)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't create the VM.")
-		return nil, err
-	}
-	log.Debug().Msgf("That's the basic information of VM: %#v", vm)
-	vm, err = wsapi.VMService.LoadVM(vm.IdVM)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't Load the VM after create.")
-		return nil, err
-	}
-	log.Debug().Msgf("With the PATH loaded: %#v", vm)
-	// These lines are just useful if the Terraform Code and the
-	// VmWare Workstation API Rest are in the same server
-	// err = wsapi.Utils.SetDenominationDescription(vm.Path, n, d)
-	// if err != nil {
-	// 	log.Error().Err(err).Msg("We have a error when we have tried to set the Denomination and Description of VM.")
-	// 	return nil, err
-	// }
-	// log.Debug().Msgf("After change the Denomination and the Description: %#v", vm)
-	// ------------------------------------------------------------------------------------
-	// The following lines were created because sometimes the VM can't get the IP
-	// when we powered the VM, that happens because the VM has the same MAC address
-	// that the ParentVM, so we delete the NIC and create it again in order to refresh
-	// the MAC address
-	net, err := wsapi.NETService.LoadNICS(vm)
+	return wsapi.CreateVMContext(context.Background(), pid, n, d, p, m, s)
+}
+
+// CreateVMContext is the same as CreateVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) CreateVMContext(ctx context.Context, pid string, n string, d string, p int32, m int32, s string) (*wsapivm.MyVm, error) {
+	return wsapi.CreateContext(ctx, wsapivm.CreateVMSpec{
+		ParentID:    pid,
+		DisplayName: n,
+		Description: d,
+		CPUs:        p,
+		Memory:      m,
+		PowerState:  wsapivm.PowerState(s),
+	})
+}
+
+// Create method to create a new VM in VmWare Workstation with a specification, it is
+// the same as CreateVM but with all the options in a struct, we validate the
+// specification before any API call.
+// Input:
+// spec: (wsapivm.CreateVMSpec) The definition of the new VM.
+// Output:
+// (*wsapivm.MyVm) The new VM with its Path.
+// error: (error) The possible error that you will have, errors.Is(err, wsapivm.ErrInvalidSpec) if the specification
+// isn't valid, or a *wsapivm.RollbackError if we couldn't configure the clone.
+func (wsapi *WSAPIClient) Create(spec wsapivm.CreateVMSpec) (*wsapivm.MyVm, error) {
+	return wsapi.CreateContext(context.Background(), spec)
+}
+
+// CreateContext is the same as Create but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) CreateContext(ctx context.Context, spec wsapivm.CreateVMSpec) (*wsapivm.MyVm, error) {
+	vm, err := wsapi.VMService.CreateContext(ctx, spec)
 	if err != nil {
-		log.Error().Err(err).Msg("We can't Load the Network of VM.")
-		return nil, err
+		wsapi.log().Error().Err(err).Msg("We can't create the VM.")
+		return vm, err
 	}
-	log.Debug().Msgf("The network information of VM: %#v", net)
-	err = wsapi.NETService.DeleteNIC(vm, net.NICS[0].Index)
+	ip := vm.IP
+	loaded, err := wsapi.VMService.LoadVMContext(ctx, vm.IdVM)
 	if err != nil {
-		log.Error().Err(err).Msg("We can't Delete the Network of VM.")
-		return nil, err
+		// The VM is complete, so we don't delete it, we give it to the caller with the error
+		wsapi.log().Error().Err(err).Msg("We can't Load the VM after create.")
+		return vm, err
 	}
-	log.Debug().Msgf("We have deleted the Network %#v the VM: %#v", net.NICS[0].Index, vm)
-	net, err = wsapi.NETService.CreateNIC(vm, net.NICS[0].Type, net.NICS[0].Vmnet)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't Create the Network of VM.")
-		return nil, err
+	vm = loaded
+	if vm.IP == "" {
+		vm.IP = ip
 	}
-	log.Debug().Msgf("We have created the Network %#v the VM: %#v", net.NICS[0].Index, vm)
-	log.Info().Msg("We have created the VM.")
-	return vm, err
+	wsapi.log().Info().Msg("We have created the VM.")
+	return vm, nil
 }
 
 // LoadVM method return the object MyVm with the ID indicate in i.
@@ -217,7 +315,13 @@ func (wsapi *WSAPIClient) CreateVM(pid string, n string, d string, p int32, m in
 // (pointer) Pointer at the MyVm object
 // (error) variable with the error if occur
 func (wsapi *WSAPIClient) LoadVM(i string) (*wsapivm.MyVm, error) {
-	return wsapi.VMService.LoadVM(i)
+	return wsapi.LoadVMContext(context.Background(), i)
+}
+
+// LoadVMContext is the same as LoadVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) LoadVMContext(ctx context.Context, i string) (*wsapivm.MyVm, error) {
+	return wsapi.VMService.LoadVMContext(ctx, i)
 }
 
 // LoadVMbyName method return the object MyVm with the Name indicate in n.
@@ -227,21 +331,106 @@ func (wsapi *WSAPIClient) LoadVM(i string) (*wsapivm.MyVm, error) {
 // (pointer) Pointer at the MyVm object
 // (error) variable with the error if occur
 func (wsapi *WSAPIClient) LoadVMbyName(n string) (*wsapivm.MyVm, error) {
-	return wsapi.VMService.LoadVMbyName(n)
+	return wsapi.LoadVMbyNameContext(context.Background(), n)
+}
+
+// LoadVMbyNameContext is the same as LoadVMbyName but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) LoadVMbyNameContext(ctx context.Context, n string) (*wsapivm.MyVm, error) {
+	return wsapi.VMService.LoadVMbyNameContext(ctx, n)
+}
+
+// LoadVMbyPath method return the object MyVm with the path of the .vmx file indicate in p.
+// Inputs:
+// p: (string) String with the path of the .vmx file of the VM
+// Outputs:
+// (pointer) Pointer at the MyVm object
+// (error) variable with the error if occur
+func (wsapi *WSAPIClient) LoadVMbyPath(p string) (*wsapivm.MyVm, error) {
+	return wsapi.LoadVMbyPathContext(context.Background(), p)
 }
 
-// UpdateVM method to update a VM in VmWare Worstation
+// LoadVMbyPathContext is the same as LoadVMbyPath but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) LoadVMbyPathContext(ctx context.Context, p string) (*wsapivm.MyVm, error) {
+	return wsapi.VMService.LoadVMbyPathContext(ctx, p)
+}
+
+// UpdateVM method to update a VM in VmWare Worstation, we only stop the VM when we
+// change the processors or the memory.
 // Input:
 // vm (*MyVm) The VM that we want to update
-// n: string with the denomination of VM
-// d: string with the description of the VM, p: int with the number of processors
+// n: string with the denomination of VM, empty means that we keep it
+// d: string with the description of the VM, empty means that we keep it
+// p: int with the number of processors
 // m: int with the size of memory
-// s: Power State desired, choose between on, off, reset, (nil no change)
+// s: Power State desired, choose between on, off, reset to restart it with the changes, (empty no change)
 // Output:
 // pointer at the MyVm object
 // and error variable with the error if occur
 func (wsapi *WSAPIClient) UpdateVM(vm *wsapivm.MyVm, n string, d string, p int32, m int32, s string) error {
-	return wsapi.VMService.UpdateVM(vm, n, d, p, m, s)
+	return wsapi.UpdateVMContext(context.Background(), vm, n, d, p, m, s)
+}
+
+// UpdateVMContext is the same as UpdateVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) UpdateVMContext(ctx context.Context, vm *wsapivm.MyVm, n string, d string, p int32, m int32, s string) error {
+	return wsapi.VMService.UpdateVMContext(ctx, vm, n, d, p, m, s)
+}
+
+// Update method change the VM until it has the specification, we only make the changes
+// that we need and we only stop the VM when vmrest doesn't allow the change while it's running.
+// Input:
+// vm: (*wsapivm.MyVm) The VM that we want to update.
+// spec: (wsapivm.UpdateVMSpec) The state that we want, the empty fields mean that we keep the current value.
+// Output:
+// (*wsapivm.UpdateReport) What we have changed and if we have restarted the VM.
+// error: (error) The possible error that you will have.
+func (wsapi *WSAPIClient) Update(vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.UpdateReport, error) {
+	return wsapi.UpdateContext(context.Background(), vm, spec)
+}
+
+// UpdateContext is the same as Update but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) UpdateContext(ctx context.Context, vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.UpdateReport, error) {
+	return wsapi.VMService.UpdateContext(ctx, vm, spec)
+}
+
+// PlanUpdate method return the changes that Update would make in the VM, without change
+// anything, we can show them with the String or the JSON methods of the plan.
+// Input:
+// vm: (*wsapivm.MyVm) The VM that we want to update.
+// spec: (wsapivm.UpdateVMSpec) The state that we want.
+// Output:
+// (*wsapivm.Plan) The changes, we can apply them with ApplyPlan.
+// error: (error) The possible error that you will have.
+func (wsapi *WSAPIClient) PlanUpdate(vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.Plan, error) {
+	return wsapi.PlanUpdateContext(context.Background(), vm, spec)
+}
+
+// PlanUpdateContext is the same as PlanUpdate but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) PlanUpdateContext(ctx context.Context, vm *wsapivm.MyVm, spec wsapivm.UpdateVMSpec) (*wsapivm.Plan, error) {
+	return wsapi.VMService.PlanUpdateContext(ctx, vm, spec)
+}
+
+// ApplyPlan method make exactly the changes of the plan, it fails without change anything
+// if the VM has changed since we made the plan or if we would need to stop the VM and the
+// plan doesn't say it.
+// Input:
+// vm: (*wsapivm.MyVm) The VM of the plan.
+// plan: (*wsapivm.Plan) The plan that PlanUpdate give us.
+// Output:
+// (*wsapivm.UpdateReport) What we have changed and if we have restarted the VM.
+// error: (error) The possible error that you will have, errors.Is(err, wsapivm.ErrDrift) if the VM has changed.
+func (wsapi *WSAPIClient) ApplyPlan(vm *wsapivm.MyVm, plan *wsapivm.Plan) (*wsapivm.UpdateReport, error) {
+	return wsapi.ApplyPlanContext(context.Background(), vm, plan)
+}
+
+// ApplyPlanContext is the same as ApplyPlan but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) ApplyPlanContext(ctx context.Context, vm *wsapivm.MyVm, plan *wsapivm.Plan) (*wsapivm.UpdateReport, error) {
+	return wsapi.VMService.ApplyPlanContext(ctx, vm, plan)
 }
 
 // RegisterVM method to register a new VM in VmWare Worstation GUI:
@@ -251,7 +440,13 @@ func (wsapi *WSAPIClient) UpdateVM(vm *wsapivm.MyVm, n string, d string, p int32
 // Output:
 // error: (error) The possible error that you will have.
 func (wsapi *WSAPIClient) RegisterVM(vm *wsapivm.MyVm) error {
-	return wsapi.VMService.RegisterVM(vm)
+	return wsapi.RegisterVMContext(context.Background(), vm)
+}
+
+// RegisterVMContext is the same as RegisterVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) RegisterVMContext(ctx context.Context, vm *wsapivm.MyVm) error {
+	return wsapi.VMService.RegisterVMContext(ctx, vm)
 }
 
 // DeleteVM method to delete a VM in VmWare Worstation
@@ -261,5 +456,127 @@ func (wsapi *WSAPIClient) RegisterVM(vm *wsapivm.MyVm) error {
 // Output:
 // error: (error) The possible error that you will have.
 func (wsapi *WSAPIClient) DeleteVM(vm *wsapivm.MyVm) error {
-	return wsapi.VMService.DeleteVM(vm)
+	return wsapi.DeleteVMContext(context.Background(), vm)
+}
+
+// DeleteVMContext is the same as DeleteVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) DeleteVMContext(ctx context.Context, vm *wsapivm.MyVm) error {
+	return wsapi.VMService.DeleteVMContext(ctx, vm)
+}
+
+// StopVM method to stop a VM in VmWare Worstation with the StopPolicy of the client,
+// by default we ask the guest OS to shut down and after a while we cut the power.
+// Input:
+// vm: (*wsapivm.MyVM) The VM object that we want to stop.
+// Output:
+// error: (error) The possible error that you will have, wsapivm.ErrInvalidState if the VM is
+// suspended or paused and the policy doesn't have DiscardState.
+func (wsapi *WSAPIClient) StopVM(vm *wsapivm.MyVm) error {
+	return wsapi.StopVMContext(context.Background(), vm)
+}
+
+// StopVMContext is the same as StopVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) StopVMContext(ctx context.Context, vm *wsapivm.MyVm) error {
+	return wsapi.VMService.StopVMContext(ctx, vm)
+}
+
+// SetStopPolicy method change the way that we stop the VMs in StopVM, UpdateVM and DeleteVM.
+// Input:
+// p: (wsapivm.StopPolicy) The policy that we want to use.
+func (wsapi *WSAPIClient) SetStopPolicy(p wsapivm.StopPolicy) {
+	wsapi.VMService.SetStopPolicy(p)
+}
+
+// WaitForPowerState method wait until the VM has the Power State that we want.
+// Input:
+// ctx: (context.Context) The context that limit the wait.
+// vm: (*wsapivm.MyVM) The VM object that we are waiting for.
+// state: (wsapivm.PowerState) The Power State that we want.
+// opts: (...wsapivm.WaitOptions) Optional settings of the wait.
+// Output:
+// error: (error) *wsapivm.WaitTimeoutError if the VM doesn't reach the state in time.
+func (wsapi *WSAPIClient) WaitForPowerState(ctx context.Context, vm *wsapivm.MyVm, state wsapivm.PowerState, opts ...wsapivm.WaitOptions) error {
+	return wsapi.VMService.WaitForPowerState(ctx, vm, state, opts...)
+}
+
+// WaitForIP method wait until the guest OS of the VM report an IP.
+// Input:
+// ctx: (context.Context) The context that limit the wait.
+// vm: (*wsapivm.MyVM) The VM object that we are waiting for.
+// opts: (...wsapivm.WaitOptions) Optional settings of the wait.
+// Output:
+// (string) The IP of the guest.
+// error: (error) *wsapivm.WaitTimeoutError if the guest doesn't have an IP in time.
+func (wsapi *WSAPIClient) WaitForIP(ctx context.Context, vm *wsapivm.MyVm, opts ...wsapivm.WaitOptions) (string, error) {
+	return wsapi.VMService.WaitForIP(ctx, vm, opts...)
+}
+
+// SetGuestNetwork method enable or disable the network information of the guest OS,
+// the IP, the NICs, the DNS, the routes, WINS and DHCP, in LoadVM, LoadVMbyName and GetAllVMs.
+// Input:
+// enabled: (bool) True if we want the network information.
+func (wsapi *WSAPIClient) SetGuestNetwork(enabled bool) {
+	wsapi.VMService.SetGuestNetwork(enabled)
+}
+
+// SetFields method choose the values of the VMs that we load in LoadVM, LoadVMbyName,
+// LoadVMbyPath and GetAllVMs, by default wsapivm.DefaultFields.
+// Input:
+// f: (wsapivm.VMField) The values that we want, like wsapivm.FieldBasicInfo | wsapivm.FieldPowerState.
+func (wsapi *WSAPIClient) SetFields(f wsapivm.VMField) {
+	wsapi.VMService.SetFields(f)
+}
+
+// SetConcurrency method change the number of VMs that GetAllVMs load at the same time.
+// Input:
+// n: (int) The number of VMs, 0 means wsapivm.DefaultConcurrency.
+func (wsapi *WSAPIClient) SetConcurrency(n int) {
+	wsapi.VMService.SetConcurrency(n)
+}
+
+// SetInventoryCache method enable or disable the cache of the IDs, the paths and the names
+// of the VMs, with it LoadVM, LoadVMbyName and LoadVMbyPath are much faster.
+// Input:
+// ttl: (time.Duration) Time that the cache is valid, 0 or less means that we don't use the cache.
+func (wsapi *WSAPIClient) SetInventoryCache(ttl time.Duration) {
+	wsapi.VMService.SetInventoryCache(ttl)
+}
+
+// Refresh method load the cache of the VMs again, it does nothing if we don't use the cache.
+// Output:
+// error: (error) The possible error that you will have.
+func (wsapi *WSAPIClient) Refresh() error {
+	return wsapi.RefreshContext(context.Background())
+}
+
+// RefreshContext is the same as Refresh but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (wsapi *WSAPIClient) RefreshContext(ctx context.Context) error {
+	return wsapi.VMService.RefreshContext(ctx)
+}
+
+// SetRollback method enable or disable the deletion of the VMs that CreateVM and Create
+// couldn't finish, by default we delete them, disable it to see what happened.
+// Input:
+// enabled: (bool) True if we want to delete them.
+func (wsapi *WSAPIClient) SetRollback(enabled bool) {
+	wsapi.VMService.SetRollback(enabled)
+}
+
+// SetMetadataStrategy method choose the way that CreateVM, Create and UpdateVM change the
+// denomination and the description of the VMs, by default we probe the API and then the .vmx file.
+// Input:
+// s: (wsapivm.MetadataStrategy) The strategy that we want to use.
+func (wsapi *WSAPIClient) SetMetadataStrategy(s wsapivm.MetadataStrategy) {
+	wsapi.VMService.SetMetadataStrategy(s)
+}
+
+// SetRequiredMetadata method say if CreateVM and Create fail when they can't change the
+// denomination and the description of the new VM, by default we keep the VM with a warning.
+// Input:
+// required: (bool) True to fail and delete the new VM.
+func (wsapi *WSAPIClient) SetRequiredMetadata(required bool) {
+	wsapi.VMService.SetRequiredMetadata(required)
 }
@@ -1,7 +1,36 @@
 package wsapiclient
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
 
 func TestConfigLog(t *testing.T) {
 
 }
+
+func TestSetLogger(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Logger
+	defer func() { log.Logger = previous }()
+	client := New(httpclient.WithLogger(zerolog.New(&logs)))
+	if client == nil {
+		t.Fatalf("We can't create the client")
+	}
+	wsapi := client.(*WSAPIClient)
+	if wsapi.Caller.Log() == &log.Logger {
+		t.Errorf("The client should use the logger of the option")
+	}
+	logger := zerolog.New(&logs).Level(zerolog.ErrorLevel)
+	client.SetLogger(&logger)
+	if wsapi.Caller.Log() != &logger {
+		t.Errorf("SetLogger hasn't changed the logger of the HTTP client")
+	}
+	if log.Logger.GetLevel() != previous.GetLevel() {
+		t.Errorf("SetLogger shouldn't change the global logger")
+	}
+}
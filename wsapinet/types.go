@@ -1,8 +1,11 @@
 package wsapinet
 
 import (
+	"context"
+
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
 	"github.com/elsudano/vmware-workstation-api-client/wsapivm"
+	"github.com/rs/zerolog"
 )
 
 // Interface with all the methods that we can use to talk with the API of VmWare Workstation Pro
@@ -11,11 +14,20 @@ type NETService interface {
 	LoadNICS(vm *wsapivm.MyVm) (*InfoNICS, error)
 	UpdateNIC(vm *wsapivm.MyVm, inx int32, t string, vnet string) (*InfoNICS, error)
 	DeleteNIC(vm *wsapivm.MyVm, inx int32) error
+	CreateNICContext(ctx context.Context, vm *wsapivm.MyVm, t string, vnet string) (*InfoNICS, error)
+	LoadNICSContext(ctx context.Context, vm *wsapivm.MyVm) (*InfoNICS, error)
+	UpdateNICContext(ctx context.Context, vm *wsapivm.MyVm, inx int32, t string, vnet string) (*InfoNICS, error)
+	DeleteNICContext(ctx context.Context, vm *wsapivm.MyVm, inx int32) error
+	SetLogger(l *zerolog.Logger)
 }
 
+// Option is a function that we can use to change the settings of the NETManager when we create it.
+type Option func(netm *NETManager)
+
 // That's the Manager to make the calls
 type NETManager struct {
 	netclient *httpclient.HTTPClient
+	logger    *zerolog.Logger
 }
 
 // This struct is to create a new NIC the information is different in APIRest of VmWare Workstation PRO
@@ -43,54 +55,6 @@ type NicPayload struct {
 	Vmnet string `json:"vmnet"`
 }
 
-// This's the complete information of Network on a VM
-type InfoNetwork struct {
-	Nics []struct {
-		Mac string   `json:"mac"`
-		Ip  []string `json:"ip"`
-		Dns struct {
-			Hostname   string   `json:"hostname"`
-			Domainname string   `json:"domainname"`
-			Server     []string `json:"server"`
-			Search     []string `json:"search"`
-		}
-		Wins struct {
-			Primary   string `json:"primary"`
-			Secondary string `json:"secondary"`
-		}
-		Dhcp4 struct {
-			Enabled  bool   `json:"enabled"`
-			Settings string `json:"settings"`
-		}
-		Dhcp6 struct {
-			Enabled  bool   `json:"enabled"`
-			Settings string `json:"settings"`
-		}
-	}
-	Routes []struct {
-		Dest      string `json:"dest"`
-		Prefix    int32  `json:"prefix"`
-		Nexthop   string `json:"nexthop"`
-		Interface int32  `json:"interface"`
-		Type      int32  `json:"type"`
-		Metric    int32  `json:"metric"`
-	}
-	Dns struct {
-		Hostname   string   `json:"hostname"`
-		Domainname string   `json:"domainname"`
-		Server     []string `json:"server"`
-		Search     []string `json:"search"`
-	}
-	Wins struct {
-		Primary   string `json:"primary"`
-		Secondary string `json:"secondary"`
-	}
-	Dhcpv4 struct {
-		Enabled  bool   `json:"enabled"`
-		Settings string `json:"settings"`
-	}
-	Dhcpv6 struct {
-		Enabled  bool   `json:"enabled"`
-		Settings string `json:"settings"`
-	}
-}
+// This's the complete information of Network on a VM, now it lives in wsapivm because
+// the VM model use it, we keep this name so the old code still works.
+type InfoNetwork = wsapivm.InfoNetwork
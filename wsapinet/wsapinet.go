@@ -1,27 +1,84 @@
 package wsapinet
 
 import (
+	"context"
+
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
 	"github.com/elsudano/vmware-workstation-api-client/wsapivm"
+	"github.com/rs/zerolog"
 )
 
 // New functon is just to create a new object NETClient to make the different calls at VmWare Workstation Pro
-func New(httpcaller *httpclient.HTTPClient) NETService {
-	return &NETManager{netclient: httpcaller}
+// opts: (...Option) Optional settings of the manager, like the logger.
+func New(httpcaller *httpclient.HTTPClient, opts ...Option) NETService {
+	netm := &NETManager{netclient: httpcaller}
+	for _, opt := range opts {
+		opt(netm)
+	}
+	return netm
+}
+
+// WithLogger option set the logger of the manager, if we don't set it
+// the manager use the same logger that the HTTP client.
+// Inputs:
+// l: (zerolog.Logger) The logger that we want to use.
+func WithLogger(l zerolog.Logger) Option {
+	return func(netm *NETManager) {
+		netm.SetLogger(&l)
+	}
+}
+
+// SetLogger method change the logger of the manager, nil means that we
+// go back to the logger of the HTTP client.
+// Inputs:
+// l: (*zerolog.Logger) The logger that we want to use.
+func (netm *NETManager) SetLogger(l *zerolog.Logger) {
+	netm.logger = l
+}
+
+// log method return the logger that the manager has to use.
+func (netm *NETManager) log() *zerolog.Logger {
+	if netm.logger != nil {
+		return netm.logger
+	}
+	return netm.netclient.Log()
 }
 
 func (netm *NETManager) LoadNICS(vm *wsapivm.MyVm) (NICS *InfoNICS, err error) {
-	return GetNics(netm.netclient, vm.IdVM)
+	return netm.LoadNICSContext(context.Background(), vm)
+}
+
+// LoadNICSContext is the same as LoadNICS but the API calls are bound to ctx.
+func (netm *NETManager) LoadNICSContext(ctx context.Context, vm *wsapivm.MyVm) (NICS *InfoNICS, err error) {
+	netm.log().Debug().Msgf("We are loading the NICs of the VM: %#v", vm.IdVM)
+	return GetNicsContext(ctx, netm.netclient, vm.IdVM)
 }
 
 func (netm *NETManager) CreateNIC(vm *wsapivm.MyVm, t string, vnet string) (NIC *InfoNICS, err error) {
-	return CreateNic(netm.netclient, vm.IdVM, t, vnet)
+	return netm.CreateNICContext(context.Background(), vm, t, vnet)
+}
+
+// CreateNICContext is the same as CreateNIC but the API calls are bound to ctx.
+func (netm *NETManager) CreateNICContext(ctx context.Context, vm *wsapivm.MyVm, t string, vnet string) (NIC *InfoNICS, err error) {
+	netm.log().Debug().Msgf("We are creating a NIC in the VM: %#v", vm.IdVM)
+	return CreateNicContext(ctx, netm.netclient, vm.IdVM, t, vnet)
 }
 
 func (netm *NETManager) UpdateNIC(vm *wsapivm.MyVm, idx int32, t string, vnet string) (NIC *InfoNICS, err error) {
+	return netm.UpdateNICContext(context.Background(), vm, idx, t, vnet)
+}
+
+// UpdateNICContext is the same as UpdateNIC but the API calls are bound to ctx.
+func (netm *NETManager) UpdateNICContext(ctx context.Context, vm *wsapivm.MyVm, idx int32, t string, vnet string) (NIC *InfoNICS, err error) {
 	return nil, nil
 }
 
 func (netm *NETManager) DeleteNIC(vm *wsapivm.MyVm, idx int32) (err error) {
-	return DeleteNic(netm.netclient, vm.IdVM, idx)
+	return netm.DeleteNICContext(context.Background(), vm, idx)
+}
+
+// DeleteNICContext is the same as DeleteNIC but the API calls are bound to ctx.
+func (netm *NETManager) DeleteNICContext(ctx context.Context, vm *wsapivm.MyVm, idx int32) (err error) {
+	netm.log().Debug().Msgf("We are deleting a NIC of the VM: %#v", vm.IdVM)
+	return DeleteNicContext(ctx, netm.netclient, vm.IdVM, idx)
 }
@@ -2,11 +2,11 @@ package wsapinet
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
-	"github.com/rs/zerolog/log"
 )
 
 // GetNetwork Method to get all the Network information of the instance
@@ -17,18 +17,22 @@ import (
 // NICS: (*InfoNICS) The structure with all the information about of the NICs that the VM has
 // err: (error) If we have some error we can handle it here.
 func GetNics(netc *httpclient.HTTPClient, vmid string) (NICS *InfoNICS, err error) {
-	response, err := netc.ApiCall("vms/"+vmid+"/nic", "GET", bytes.Buffer{})
+	return GetNicsContext(context.Background(), netc, vmid)
+}
+
+// GetNicsContext is the same as GetNics but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetNicsContext(ctx context.Context, netc *httpclient.HTTPClient, vmid string) (NICS *InfoNICS, err error) {
+	response, err := netc.ApiCallContext(ctx, "vms/"+vmid+"/nic", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return NICS, err
+		return NICS, fmt.Errorf("get NICs of VM %q: %w", vmid, err)
 	}
 	err = json.NewDecoder(response).Decode(&NICS)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return NICS, err
+		return NICS, fmt.Errorf("get NICs of VM %q: decoding response: %w", vmid, err)
 	}
-	log.Debug().Msgf("These's are the NIC's: %#v", NICS)
-	log.Info().Msg("We have read the Network Information.")
+	netc.Log().Debug().Msgf("These's are the NIC's: %#v", NICS)
+	netc.Log().Info().Msg("We have read the Network Information.")
 	return NICS, nil
 }
 
@@ -39,6 +43,12 @@ func GetNics(netc *httpclient.HTTPClient, vmid string) (NICS *InfoNICS, err erro
 // NICS: (*InfoNICS) The structure with all the information about of the NICs that the VM has
 // err: (error) If we have some error we can handle it here.
 func CreateNic(netc *httpclient.HTTPClient, vmid string, t string, vnet string) (NIC *InfoNICS, err error) {
+	return CreateNicContext(context.Background(), netc, vmid, t, vnet)
+}
+
+// CreateNicContext is the same as CreateNic but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func CreateNicContext(ctx context.Context, netc *httpclient.HTTPClient, vmid string, t string, vnet string) (NIC *InfoNICS, err error) {
 	var DataNIC NicPayload
 	var newNIC NewNIC
 	NIC = new(InfoNICS)
@@ -52,24 +62,21 @@ func CreateNic(netc *httpclient.HTTPClient, vmid string, t string, vnet string)
 	}
 	err = json.NewEncoder(requestBody).Encode(&DataNIC)
 	if err != nil {
-		log.Error().Err(err).Msg("The NIC JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("create NIC in VM %q: encoding request: %w", vmid, err)
 	}
-	log.Debug().Msgf("Request RAW: %#v", requestBody.String())
-	response, err := netc.ApiCall("vms/"+vmid+"/nic", "POST", *requestBody)
+	netc.Log().Debug().Msgf("Request RAW: %#v", netc.Redact(requestBody.String()))
+	response, err := netc.ApiCallContext(ctx, "vms/"+vmid+"/nic", "POST", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call creating NIC.")
-		return nil, err
+		return nil, fmt.Errorf("create NIC in VM %q: %w", vmid, err)
 	}
 	err = json.NewDecoder(response).Decode(&newNIC)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("create NIC in VM %q: decoding response: %w", vmid, err)
 	}
 	NIC.Num = 1
 	NIC.NICS = append(NIC.NICS, newNIC)
-	log.Debug().Msgf("Info of new NIC: %#v", NIC)
-	log.Info().Msg("We have created the NIC.")
+	netc.Log().Debug().Msgf("Info of new NIC: %#v", NIC)
+	netc.Log().Info().Msg("We have created the NIC.")
 	return NIC, nil
 }
 
@@ -82,13 +89,18 @@ func CreateNic(netc *httpclient.HTTPClient, vmid string, t string, vnet string)
 // NICS: (*InfoNICS) The structure with all the information about of the NICs that the VM has.
 // err: (error) If we have some error we can handle it here.
 func DeleteNic(netc *httpclient.HTTPClient, vmid string, idx int32) (err error) {
-	_, err = netc.ApiCall("vms/"+vmid+"/nic/"+fmt.Sprint(idx), "DELETE", bytes.Buffer{})
+	return DeleteNicContext(context.Background(), netc, vmid, idx)
+}
+
+// DeleteNicContext is the same as DeleteNic but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func DeleteNicContext(ctx context.Context, netc *httpclient.HTTPClient, vmid string, idx int32) (err error) {
+	_, err = netc.ApiCallContext(ctx, "vms/"+vmid+"/nic/"+fmt.Sprint(idx), "DELETE", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("delete NIC %d of VM %q: %w", idx, vmid, err)
 	}
-	log.Debug().Msgf("We have deleted this NIC: %#v", fmt.Sprint(idx))
-	log.Info().Msg("We have Deleted the NIC.")
+	netc.Log().Debug().Msgf("We have deleted this NIC: %#v", fmt.Sprint(idx))
+	netc.Log().Info().Msg("We have Deleted the NIC.")
 	return err
 }
 
@@ -101,6 +113,12 @@ func DeleteNic(netc *httpclient.HTTPClient, vmid string, idx int32) (err error)
 // Outputs:
 // error: (error) We can handle the errors here.
 func RenewMAC(netc *httpclient.HTTPClient, vmid string) (err error) {
+	return RenewMACContext(context.Background(), netc, vmid)
+}
+
+// RenewMACContext is the same as RenewMAC but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func RenewMACContext(ctx context.Context, netc *httpclient.HTTPClient, vmid string) (err error) {
 	var currentNIC InfoNICS
 	var newNIC NicPayload
 	requestBody := new(bytes.Buffer)
@@ -109,20 +127,17 @@ func RenewMAC(netc *httpclient.HTTPClient, vmid string) (err error) {
 	// 	log.Printf("[ERROR][WSAPICLI] Fi: wsapivm.go Fu: RenewMAC Obj: We can't shutdown the VM %#v\n", err)
 	// 	return err
 	// }
-	response, err := netc.ApiCall("vms/"+vmid+"/nic", "GET", bytes.Buffer{})
+	response, err := netc.ApiCallContext(ctx, "vms/"+vmid+"/nic", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("renew MAC of VM %q: %w", vmid, err)
 	}
 	err = json.NewDecoder(response).Decode(&currentNIC)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("renew MAC of VM %q: decoding response: %w", vmid, err)
 	}
-	_, err = netc.ApiCall("vms/"+vmid+"/nic/"+fmt.Sprint(currentNIC.NICS[0].Index), "DELETE", bytes.Buffer{})
+	_, err = netc.ApiCallContext(ctx, "vms/"+vmid+"/nic/"+fmt.Sprint(currentNIC.NICS[0].Index), "DELETE", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call to delete the NIC.")
-		return err
+		return fmt.Errorf("renew MAC of VM %q: %w", vmid, err)
 	}
 	if currentNIC.NICS[0].Type == "bridged" {
 		newNIC.Type = currentNIC.NICS[0].Type
@@ -133,16 +148,14 @@ func RenewMAC(netc *httpclient.HTTPClient, vmid string) (err error) {
 	}
 	err = json.NewEncoder(requestBody).Encode(&newNIC)
 	if err != nil {
-		log.Error().Err(err).Msg("The NIC JSON is malformed.")
-		return err
+		return fmt.Errorf("renew MAC of VM %q: encoding request: %w", vmid, err)
 	}
-	log.Debug().Msgf("Request RAW: %#v", requestBody.String())
-	_, err = netc.ApiCall("vms/"+vmid+"/nic", "POST", *requestBody)
+	netc.Log().Debug().Msgf("Request RAW: %#v", netc.Redact(requestBody.String()))
+	_, err = netc.ApiCallContext(ctx, "vms/"+vmid+"/nic", "POST", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call to create the NIC.")
-		return err
+		return fmt.Errorf("renew MAC of VM %q: %w", vmid, err)
 	}
-	log.Debug().Msgf("VM: %#v", currentNIC)
-	log.Info().Msg("We have changed the MAC address.")
+	netc.Log().Debug().Msgf("VM: %#v", currentNIC)
+	netc.Log().Info().Msg("We have changed the MAC address.")
 	return err
 }
@@ -1,10 +1,67 @@
 package wsapinet
 
-import "testing"
+import (
+	"testing"
 
-func TestGetInfoNics(t *testing.T) {
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
 
+// newTestClient Auxiliary function to start a fake vmrest server and a client connected to it.
+func newTestClient(t *testing.T, vms ...wsapitest.VM) (*httpclient.HTTPClient, *wsapitest.Server) {
+	t.Helper()
+	server := wsapitest.NewServer(vms...)
+	t.Cleanup(server.Close)
+	netc, err := httpclient.NewClient(server.URL, server.User, server.Password, false, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	return netc, server
+}
+
+// networkVM is the VM that we use in the tests.
+var networkVM = wsapitest.VM{
+	ID:   "VMID",
+	NICs: []wsapitest.NIC{{Index: 1, Type: "custom", Vmnet: "vmnet2", MacAddress: "00:50:56:00:00:01"}},
 }
-func TestRenewMAC(t *testing.T) {
 
+func TestGetInfoNics(t *testing.T) {
+	netc, _ := newTestClient(t, networkVM)
+	nics, err := GetNics(netc, "VMID")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if nics.Num != 1 || nics.NICS[0].Vmnet != "vmnet2" || nics.NICS[0].Mac != "00:50:56:00:00:01" {
+		t.Errorf("We haven't read the Network Information: %#v", nics)
+	}
+}
+func TestCreateDeleteNic(t *testing.T) {
+	netc, server := newTestClient(t, networkVM)
+	nic, err := CreateNic(netc, "VMID", "bridged", "vmnet0")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("VMID")
+	if nic.NICS[0].Index != 2 || len(stored.NICs) != 2 || stored.NICs[1].Vmnet != "" {
+		t.Errorf("We haven't created the bridged NIC: %#v %#v", nic, stored.NICs)
+	}
+	err = DeleteNic(netc, "VMID", 1)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ = server.VM("VMID")
+	if len(stored.NICs) != 1 || stored.NICs[0].Index != 2 {
+		t.Errorf("We haven't deleted the NIC: %#v", stored.NICs)
+	}
+}
+func TestRenewMAC(t *testing.T) {
+	netc, server := newTestClient(t, networkVM)
+	err := RenewMAC(netc, "VMID")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("VMID")
+	if len(stored.NICs) != 1 || stored.NICs[0].MacAddress == "00:50:56:00:00:01" || stored.NICs[0].Vmnet != "vmnet2" {
+		t.Errorf("We haven't changed the MAC address: %#v", stored.NICs)
+	}
 }
@@ -0,0 +1,190 @@
+package wsapitest
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Fault is a rule of the scenario of the fake server, it says what goes wrong with
+// the API calls that match the method and the path. We create it with Server.On and
+// we describe it with its methods, like:
+//
+//	server.On("PUT", "vms/*/power").After(1).Times(2).Fail(500, 0, "Internal error")
+//	server.On("DELETE", "vms/*/nic/*").Delay(3 * time.Second)
+//	server.On("GET", "vms").Drop()
+//
+// The rules are deterministic, they only depend on the order of the API calls.
+type Fault struct {
+	server    *Server
+	method    string
+	pattern   string
+	after     int
+	times     int
+	delay     time.Duration
+	status    int
+	code      int
+	message   string
+	drop      bool
+	malformed bool
+	matched   int
+	applied   int
+}
+
+// On method add a new rule to the scenario of the server, the rules are
+// evaluated in the same order that we have added them.
+// Inputs:
+// method: (string) The HTTP method, an empty string match all of them.
+// pattern: (string) The path without the /api prefix, like vms/*/power, with the
+// syntax of path.Match, an empty string match all the paths.
+// Outputs:
+// (*Fault) The rule, we use its methods to say what goes wrong.
+func (s *Server) On(method string, pattern string) *Fault {
+	f := &Fault{server: s, method: strings.ToUpper(method), pattern: strings.Trim(pattern, "/")}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, f)
+	return f
+}
+
+// ClearFaults method remove all the rules of the scenario.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// After method skip the first n API calls that match the rule.
+func (f *Fault) After(n int) *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.after = n
+	return f
+}
+
+// Times method limit the rule to n API calls, by default the rule applies always.
+func (f *Fault) Times(n int) *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.times = n
+	return f
+}
+
+// Once method is the same as Times(1).
+func (f *Fault) Once() *Fault {
+	return f.Times(1)
+}
+
+// Delay method make the server wait d before it attends the API call, if the
+// rule doesn't fail the call the server answer as usual after the delay.
+func (f *Fault) Delay(d time.Duration) *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.delay = d
+	return f
+}
+
+// Fail method make the server answer with the HTTP status and a VmError with
+// the code and the message, like vmrest does.
+func (f *Fault) Fail(status int, code int, message string) *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.status = status
+	f.code = code
+	f.message = message
+	return f
+}
+
+// Busy method make the server answer like vmrest when the VM is locked by another operation.
+func (f *Fault) Busy() *Fault {
+	return f.Fail(http.StatusConflict, CodeInUse, "The virtual machine is in use by another operation")
+}
+
+// Drop method make the server close the connection without answer.
+func (f *Fault) Drop() *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.drop = true
+	return f
+}
+
+// Malformed method make the server answer with a body that isn't valid JSON,
+// with the status of Fail or 200 if we haven't called Fail.
+func (f *Fault) Malformed() *Fault {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	f.malformed = true
+	return f
+}
+
+// Applied method return how many API calls the rule has changed.
+func (f *Fault) Applied() int {
+	f.server.mu.Lock()
+	defer f.server.mu.Unlock()
+	return f.applied
+}
+
+// match method return true if the rule has to change the API call, the caller must hold the lock.
+func (f *Fault) match(method string, p string) bool {
+	if f.method != "" && f.method != method {
+		return false
+	}
+	if f.pattern != "" {
+		ok, err := path.Match(f.pattern, strings.TrimPrefix(p, "/api/"))
+		if err != nil || !ok {
+			return false
+		}
+	}
+	f.matched++
+	if f.matched <= f.after || (f.times > 0 && f.applied >= f.times) {
+		return false
+	}
+	f.applied++
+	return true
+}
+
+// fault method return a copy of the first rule that match the API call, nil if there isn't.
+func (s *Server) fault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.faults {
+		if f.match(r.Method, r.URL.Path) {
+			snapshot := *f
+			return &snapshot
+		}
+	}
+	return nil
+}
+
+// inject method apply the rule at the API call, it return true if the call has
+// been attended by the rule and we don't have to send it to the endpoint.
+func (f *Fault) inject(w http.ResponseWriter, r *http.Request) bool {
+	if f.delay > 0 {
+		timer := time.NewTimer(f.delay)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return true
+		case <-timer.C:
+		}
+	}
+	switch {
+	case f.drop:
+		// That's the way to close the connection without answer and without noise in the logs
+		panic(http.ErrAbortHandler)
+	case f.malformed:
+		status := f.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id": "`))
+		return true
+	case f.status != 0:
+		writeError(w, f.status, f.code, f.message)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package wsapitest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFaultCounting(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	fault := s.On("get", "vms/*/power").After(1).Times(2).Fail(http.StatusInternalServerError, 0, "Internal error")
+	var want = []int{http.StatusOK, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK}
+	for pos, status := range want {
+		if got := call(t, s, "GET", "vms/VMID/power", "", nil); got != status {
+			t.Errorf("The call %d should be %d, we have %d", pos, status, got)
+		}
+	}
+	if call(t, s, "GET", "vms/VMID", "", nil) != http.StatusOK {
+		t.Errorf("The rule shouldn't change other paths")
+	}
+	if fault.Applied() != 2 {
+		t.Errorf("The rule should be applied 2 times, we have %d", fault.Applied())
+	}
+	s.ClearFaults()
+	s.On("PUT", "vms/*/power").Once().Busy()
+	var vmerr vmrestError
+	if call(t, s, "PUT", "vms/VMID/power", "on", &vmerr) != http.StatusConflict || vmerr.Code != CodeInUse {
+		t.Errorf("The VM should be busy: %#v", vmerr)
+	}
+	if call(t, s, "PUT", "vms/VMID/power", "on", nil) != http.StatusOK {
+		t.Errorf("The rule should be applied just once")
+	}
+}
+
+func TestFaultDelay(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	s.On("DELETE", "vms/*/nic/*").Delay(50 * time.Millisecond)
+	s.AddVM(VM{ID: "NICS", NICs: []NIC{{Index: 1, Type: "nat"}}})
+	start := time.Now()
+	status := call(t, s, "DELETE", "vms/NICS/nic/1", "", nil)
+	if status != http.StatusNoContent || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("The call should be delayed and then attended: %d after %s", status, time.Since(start))
+	}
+}
+
+func TestFaultDropAndMalformed(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	s.On("GET", "vms").Once().Drop()
+	s.On("GET", "vms/*").Malformed()
+	req, _ := http.NewRequest("GET", s.URL+"/vms", nil)
+	req.SetBasicAuth(s.User, s.Password)
+	response, err := http.DefaultClient.Do(req)
+	if err == nil {
+		response.Body.Close()
+		t.Errorf("The connection should be closed without answer")
+	}
+	var info vmInfoPayload
+	if call(t, s, "GET", "vms/VMID", "", &info) != http.StatusOK || info.ID != "" {
+		t.Errorf("The body should be malformed: %#v", info)
+	}
+}
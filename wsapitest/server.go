@@ -0,0 +1,673 @@
+package wsapitest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUser     = "Admin"
+	defaultPassword = "Adm1n#00"
+	contentType     = "application/vnd.vmware.vmw.rest-v1+json"
+)
+
+// NewServer function start a fake vmrest server in http with the VMs that we want.
+// Inputs:
+// vms: (...VM) The VMs that the server has at the beginning.
+// Outputs:
+// (*Server) The server, you have to call Close when you finish.
+func NewServer(vms ...VM) *Server {
+	s := newServer(vms...)
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL + "/api"
+	return s
+}
+
+// NewTLSServer function is the same as NewServer but the server use https with
+// a self-signed certificate, like the one that `make api_start` generates.
+// Inputs:
+// vms: (...VM) The VMs that the server has at the beginning.
+// Outputs:
+// (*Server) The server, you have to call Close when you finish.
+func NewTLSServer(vms ...VM) *Server {
+	s := newServer(vms...)
+	s.server = httptest.NewTLSServer(s)
+	s.URL = s.server.URL + "/api"
+	return s
+}
+
+// newServer Auxiliary function to create the server without start it.
+func newServer(vms ...VM) *Server {
+	s := &Server{
+		User:     defaultUser,
+		Password: defaultPassword,
+		vms:      make(map[string]*VM),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/vms", s.listVMs)
+	mux.HandleFunc("POST /api/vms", s.cloneVM)
+	mux.HandleFunc("POST /api/vms/registration", s.registerVM)
+	mux.HandleFunc("GET /api/vms/{id}", s.getVM)
+	mux.HandleFunc("PUT /api/vms/{id}", s.updateVM)
+	mux.HandleFunc("DELETE /api/vms/{id}", s.deleteVM)
+	mux.HandleFunc("GET /api/vms/{id}/params/{name}", s.getParam)
+	mux.HandleFunc("PUT /api/vms/{id}/params", s.setParam)
+	mux.HandleFunc("PUT /api/vms/{id}/configparams", s.setParam)
+	mux.HandleFunc("GET /api/vms/{id}/power", s.getPower)
+	mux.HandleFunc("PUT /api/vms/{id}/power", s.setPower)
+	mux.HandleFunc("GET /api/vms/{id}/ip", s.getIP)
+	mux.HandleFunc("GET /api/vms/{id}/nicips", s.getNICIPs)
+	mux.HandleFunc("GET /api/vms/{id}/nic", s.listNICs)
+	mux.HandleFunc("POST /api/vms/{id}/nic", s.createNIC)
+	mux.HandleFunc("PUT /api/vms/{id}/nic/{index}", s.updateNIC)
+	mux.HandleFunc("DELETE /api/vms/{id}/nic/{index}", s.deleteNIC)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "The resource "+r.Method+" "+r.URL.Path+" doesn't exist")
+	})
+	s.handler = mux
+	for _, vm := range vms {
+		s.AddVM(vm)
+	}
+	return s
+}
+
+// Close method stop the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Certificate method return the certificate of the server when we use NewTLSServer.
+func (s *Server) Certificate() *x509.Certificate {
+	return s.server.Certificate()
+}
+
+// ServeHTTP method to implement the http.Handler interface, we record the request,
+// check the credentials, apply the faults of the scenario and then we send it to
+// the right endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	// The library sometimes send paths like /api//vms, vmrest accept them
+	r.URL.Path = path.Clean(r.URL.Path)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Body: string(body)})
+	s.mu.Unlock()
+	user, password, ok := r.BasicAuth()
+	if !ok || user != s.User || password != s.Password {
+		writeError(w, http.StatusUnauthorized, CodeAuthentication, "Authentication failed")
+		return
+	}
+	if f := s.fault(r); f != nil && f.inject(w, r) {
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// AddVM method add a VM to the server, if the ID or the Path are empty we generate them.
+// Inputs:
+// vm: (VM) The VM that we want to add.
+// Outputs:
+// (VM) The VM as the server has it.
+func (s *Server) AddVM(vm VM) VM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addVM(vm).copy()
+}
+
+// VM method return a copy of the VM with the ID i.
+// Inputs:
+// i: (string) The ID of the VM.
+// Outputs:
+// (VM) The VM.
+// (bool) False if the server doesn't have this VM.
+func (s *Server) VM(i string) (VM, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.vms[i]
+	if !ok {
+		return VM{}, false
+	}
+	return vm.copy(), true
+}
+
+// VMs method return a copy of all the VMs of the server, in the same order that the API.
+func (s *Server) VMs() []VM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vms := make([]VM, 0, len(s.order))
+	for _, i := range s.order {
+		vms = append(vms, s.vms[i].copy())
+	}
+	return vms
+}
+
+// SetPowerState method change the power state of a VM without an API call, it's
+// useful to simulate what the user does in the GUI.
+// Inputs:
+// i: (string) The ID of the VM.
+// state: (string) One of PoweredOn, PoweredOff, Suspended or Paused.
+// Outputs:
+// (bool) False if the server doesn't have this VM.
+func (s *Server) SetPowerState(i string, state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.vms[i]
+	if ok {
+		vm.PowerState = state
+		vm.shutdownLeft = 0
+	}
+	return ok
+}
+
+// SetIP method change the IP that the guest of a VM report, an empty IP
+// simulate a guest that hasn't got the network ready yet.
+// Inputs:
+// i: (string) The ID of the VM.
+// ip: (string) The new IP.
+// Outputs:
+// (bool) False if the server doesn't have this VM.
+func (s *Server) SetIP(i string, ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.vms[i]
+	if ok {
+		vm.IP = ip
+	}
+	return ok
+}
+
+// Requests method return all the API calls that the server has received.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CountRequests method return how many API calls with this method and path
+// the server has received, the path is without the /api prefix, like vms/ID/power.
+func (s *Server) CountRequests(method string, p string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, request := range s.requests {
+		if request.Method == method && request.Path == "/api/"+strings.TrimPrefix(p, "/") {
+			count++
+		}
+	}
+	return count
+}
+
+// ResetRequests method forget all the API calls that the server has received.
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+// addVM method add the VM, the caller must hold the lock.
+func (s *Server) addVM(vm VM) *VM {
+	stored := vm.copy()
+	if stored.ID == "" {
+		s.nextID++
+		stored.ID = fmt.Sprintf("%032X", s.nextID)
+	}
+	if stored.DisplayName == "" {
+		stored.DisplayName = stored.ID
+	}
+	if stored.Path == "" {
+		stored.Path = "/vmware/" + stored.DisplayName + "/" + stored.DisplayName + ".vmx"
+	}
+	if stored.PowerState == "" {
+		stored.PowerState = PoweredOff
+	}
+	if stored.Params == nil {
+		stored.Params = make(map[string]string)
+	}
+	if _, ok := s.vms[stored.ID]; !ok {
+		s.order = append(s.order, stored.ID)
+	}
+	s.vms[stored.ID] = &stored
+	return &stored
+}
+
+// lookup method return the VM of the request or write the not found error.
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*VM, bool) {
+	vm, ok := s.vms[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, CodeNotFound, "The virtual machine "+r.PathValue("id")+" doesn't exist")
+	}
+	return vm, ok
+}
+
+// listVMs method attend GET vms, the list of VMs with their ID and Path.
+func (s *Server) listVMs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vms := make([]vmPayload, 0, len(s.order))
+	for _, i := range s.order {
+		vms = append(vms, vmPayload{ID: i, Path: s.vms[i].Path})
+	}
+	writeJSON(w, http.StatusOK, vms)
+}
+
+// cloneVM method attend POST vms, the clone of a VM with the settings of the parent.
+func (s *Server) cloneVM(w http.ResponseWriter, r *http.Request) {
+	var payload clonePayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent, ok := s.vms[payload.ParentID]
+	if !ok {
+		writeError(w, http.StatusNotFound, CodeNotFound, "The parent virtual machine "+payload.ParentID+" doesn't exist")
+		return
+	}
+	if payload.Name == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "The name of the new virtual machine is empty")
+		return
+	}
+	clone := parent.copy()
+	clone.ID = ""
+	clone.Path = ""
+	clone.DisplayName = payload.Name
+	clone.PowerState = PoweredOff
+	vm := s.addVM(clone)
+	writeJSON(w, http.StatusCreated, vm.info())
+}
+
+// registerVM method attend POST vms/registration, the registration of a .vmx file.
+func (s *Server) registerVM(w http.ResponseWriter, r *http.Request) {
+	var payload registerPayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, vm := range s.vms {
+		if vm.Path == payload.Path {
+			writeError(w, http.StatusConflict, CodeAlreadyExists, "The virtual machine "+payload.Path+" is already registered")
+			return
+		}
+	}
+	vm := s.addVM(VM{DisplayName: payload.Name, Path: payload.Path})
+	writeJSON(w, http.StatusCreated, vmPayload{ID: vm.ID, Path: vm.Path})
+}
+
+// getVM method attend GET vms/{id}, the processors and the memory of the VM.
+func (s *Server) getVM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, vm.info())
+	}
+}
+
+// updateVM method attend PUT vms/{id}, vmrest only allow it when the VM is powered off.
+func (s *Server) updateVM(w http.ResponseWriter, r *http.Request) {
+	var payload settingPayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if vm.PowerState != PoweredOff {
+		writeError(w, http.StatusConflict, CodeInvalidState, "The operation is not allowed in the current state of the virtual machine")
+		return
+	}
+	if payload.Processors > 0 {
+		vm.Processors = payload.Processors
+	}
+	if payload.Memory > 0 {
+		vm.Memory = payload.Memory
+	}
+	writeJSON(w, http.StatusOK, vm.info())
+}
+
+// deleteVM method attend DELETE vms/{id}, vmrest only allow it when the VM is powered off.
+func (s *Server) deleteVM(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if vm.PowerState != PoweredOff {
+		writeError(w, http.StatusConflict, CodeInvalidState, "The virtual machine is in use, power it off before delete it")
+		return
+	}
+	delete(s.vms, vm.ID)
+	for pos, i := range s.order {
+		if i == vm.ID {
+			s.order = append(s.order[:pos], s.order[pos+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getParam method attend GET vms/{id}/params/{name}.
+func (s *Server) getParam(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if ok {
+		name := r.PathValue("name")
+		writeJSON(w, http.StatusOK, paramPayload{Name: name, Value: vm.param(name)})
+	}
+}
+
+// setParam method attend PUT vms/{id}/params and PUT vms/{id}/configparams.
+func (s *Server) setParam(w http.ResponseWriter, r *http.Request) {
+	var payload paramPayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if payload.Name == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "The name of the parameter is empty")
+		return
+	}
+	vm.setParam(payload.Name, payload.Value)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getPower method attend GET vms/{id}/power.
+func (s *Server) getPower(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if vm.shutdownLeft > 0 {
+		vm.shutdownLeft--
+		if vm.shutdownLeft == 0 {
+			vm.PowerState = PoweredOff
+		}
+	}
+	writeJSON(w, http.StatusOK, powerPayload{Value: vm.PowerState})
+}
+
+// setPower method attend PUT vms/{id}/power, the body is the operation, like on or off.
+func (s *Server) setPower(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	operation := strings.TrimSpace(string(body))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	next, ok := nextPowerState(vm.PowerState, operation)
+	if !ok {
+		writeError(w, http.StatusConflict, CodeInvalidState, "The operation "+operation+" is not allowed when the virtual machine is "+vm.PowerState)
+		return
+	}
+	if next == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "The power operation "+operation+" isn't valid")
+		return
+	}
+	vm.shutdownLeft = 0
+	switch {
+	case operation == "shutdown" && vm.PowerState == PoweredOn && vm.ShutdownPolls < 0:
+		// The guest ignore the request, the VM keep running
+	case operation == "shutdown" && vm.PowerState == PoweredOn && vm.ShutdownPolls > 0:
+		vm.shutdownLeft = vm.ShutdownPolls
+	default:
+		vm.PowerState = next
+	}
+	writeJSON(w, http.StatusOK, powerPayload{Value: vm.PowerState})
+}
+
+// getIP method attend GET vms/{id}/ip, like vmrest it fails while the VM is off or the guest hasn't an IP.
+func (s *Server) getIP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	switch {
+	case vm.PowerState != PoweredOn:
+		writeError(w, http.StatusConflict, CodeInvalidState, "The virtual machine is not powered on")
+	case vm.IP == "":
+		writeError(w, http.StatusInternalServerError, CodeInvalidState, "Unable to get the IP address")
+	default:
+		writeJSON(w, http.StatusOK, ipPayload{IP: vm.IP})
+	}
+}
+
+// getNICIPs method attend GET vms/{id}/nicips, the first NIC of the VM has the IP, the
+// gateway is the .1 of its network and the guest use DHCP.
+func (s *Server) getNICIPs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	switch {
+	case vm.PowerState != PoweredOn:
+		writeError(w, http.StatusConflict, CodeInvalidState, "The virtual machine is not powered on")
+	case vm.IP == "":
+		writeError(w, http.StatusInternalServerError, CodeInvalidState, "Unable to get the network information")
+	default:
+		writeJSON(w, http.StatusOK, vm.network())
+	}
+}
+
+// listNICs method attend GET vms/{id}/nic.
+func (s *Server) listNICs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, nicsPayload{Num: len(vm.NICs), NICS: append([]NIC{}, vm.NICs...)})
+	}
+}
+
+// createNIC method attend POST vms/{id}/nic, the new NIC has a new MAC address.
+func (s *Server) createNIC(w http.ResponseWriter, r *http.Request) {
+	var payload nicPayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if payload.Type == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "The type of the NIC is empty")
+		return
+	}
+	nic := NIC{Index: 1, Type: payload.Type, Vmnet: payload.Vmnet, MacAddress: s.newMAC()}
+	for _, item := range vm.NICs {
+		if item.Index >= nic.Index {
+			nic.Index = item.Index + 1
+		}
+	}
+	vm.NICs = append(vm.NICs, nic)
+	writeJSON(w, http.StatusCreated, nic)
+}
+
+// updateNIC method attend PUT vms/{id}/nic/{index}.
+func (s *Server) updateNIC(w http.ResponseWriter, r *http.Request) {
+	var payload nicPayload
+	if !readJSON(w, r, &payload) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	pos, ok := vm.nic(w, r.PathValue("index"))
+	if !ok {
+		return
+	}
+	vm.NICs[pos].Type = payload.Type
+	vm.NICs[pos].Vmnet = payload.Vmnet
+	writeJSON(w, http.StatusOK, vm.NICs[pos])
+}
+
+// deleteNIC method attend DELETE vms/{id}/nic/{index}.
+func (s *Server) deleteNIC(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	pos, ok := vm.nic(w, r.PathValue("index"))
+	if !ok {
+		return
+	}
+	vm.NICs = append(vm.NICs[:pos], vm.NICs[pos+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newMAC method generate a new MAC address in the range that VmWare use for the generated ones, the caller must hold the lock.
+func (s *Server) newMAC() string {
+	s.nextMAC++
+	return fmt.Sprintf("00:0c:29:%02x:%02x:%02x", byte(s.nextMAC>>16), byte(s.nextMAC>>8), byte(s.nextMAC))
+}
+
+// copy method return a deep copy of the VM.
+func (vm *VM) copy() VM {
+	c := *vm
+	c.NICs = append([]NIC(nil), vm.NICs...)
+	c.Params = make(map[string]string, len(vm.Params))
+	for key, value := range vm.Params {
+		c.Params[key] = value
+	}
+	return c
+}
+
+// network method return the network information that the guest of the VM report.
+func (vm *VM) network() nicIPsPayload {
+	gateway := vm.IP[:strings.LastIndex(vm.IP, ".")+1] + "1"
+	dns := guestDNSPayload{Hostname: vm.DisplayName, Domainname: "localdomain", Server: []string{gateway}, Search: []string{"localdomain"}}
+	dhcp := guestDHCPPayload{Enabled: true}
+	var payload nicIPsPayload
+	for pos, nic := range vm.NICs {
+		guestNIC := guestNICPayload{Mac: nic.MacAddress, DNS: dns, DHCP4: dhcp}
+		if pos == 0 {
+			guestNIC.IP = []string{vm.IP + "/24"}
+		}
+		payload.NICs = append(payload.NICs, guestNIC)
+	}
+	payload.Routes = []guestRoutePayload{{Dest: "0.0.0.0", Prefix: 0, Nexthop: gateway, Metric: 100}}
+	payload.DNS = dns
+	payload.DHCPv4 = dhcp
+	return payload
+}
+
+// info method return the body that vmrest give us with the information of the VM.
+func (vm *VM) info() vmInfoPayload {
+	var info vmInfoPayload
+	info.ID = vm.ID
+	info.CPU.Processors = vm.Processors
+	info.Memory = vm.Memory
+	return info
+}
+
+// param method return the value of a parameter of the .vmx file.
+func (vm *VM) param(name string) string {
+	switch name {
+	case "displayName":
+		return vm.DisplayName
+	case "annotation":
+		return vm.Annotation
+	}
+	return vm.Params[name]
+}
+
+// setParam method change the value of a parameter of the .vmx file.
+func (vm *VM) setParam(name string, value string) {
+	switch name {
+	case "displayName":
+		vm.DisplayName = value
+	case "annotation":
+		vm.Annotation = value
+	default:
+		vm.Params[name] = value
+	}
+}
+
+// nic method return the position of the NIC with the index i or write the not found error.
+func (vm *VM) nic(w http.ResponseWriter, i string) (int, bool) {
+	index, err := strconv.Atoi(i)
+	if err == nil {
+		for pos, item := range vm.NICs {
+			if int(item.Index) == index {
+				return pos, true
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, CodeNotFound, "The NIC "+i+" doesn't exist")
+	return 0, false
+}
+
+// nextPowerState Auxiliary function that return the power state after the operation,
+// an empty state when the operation doesn't exist and false when it isn't allowed.
+func nextPowerState(current string, operation string) (string, bool) {
+	switch operation {
+	case "on":
+		return PoweredOn, true
+	case "off", "shutdown":
+		return PoweredOff, true
+	case "reset":
+		return PoweredOn, current == PoweredOn
+	case "suspend":
+		return Suspended, current == PoweredOn
+	case "pause":
+		return Paused, current == PoweredOn
+	case "unpause":
+		return PoweredOn, current == Paused
+	}
+	return "", true
+}
+
+// readJSON Auxiliary function to decode the body of the request or write the error.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "The body of the request isn't valid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON Auxiliary function to send a response in JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError Auxiliary function to send an error with the same body that vmrest.
+func writeError(w http.ResponseWriter, status int, code int, message string) {
+	writeJSON(w, status, vmrestError{Code: code, Message: message})
+}
@@ -0,0 +1,163 @@
+package wsapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// call Auxiliary function to make a request to the fake server.
+func call(t *testing.T, s *Server, method string, p string, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, s.URL+"/"+p, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	req.SetBasicAuth(s.User, s.Password)
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	defer response.Body.Close()
+	if out != nil {
+		json.NewDecoder(response.Body).Decode(out)
+	}
+	return response.StatusCode
+}
+
+func TestServerAuthentication(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	response, err := http.Get(s.URL + "/vms")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Without credentials the status should be 401, we have %d", response.StatusCode)
+	}
+}
+
+func TestServerCloneAndDelete(t *testing.T) {
+	s := NewServer(VM{ID: "PARENT", Processors: 2, Memory: 1024, NICs: []NIC{{Index: 1, Type: "nat", Vmnet: "vmnet8", MacAddress: "00:50:56:00:00:01"}}})
+	defer s.Close()
+	var info vmInfoPayload
+	status := call(t, s, "POST", "vms", `{"name":"clone","parentId":"PARENT"}`, &info)
+	if status != http.StatusCreated || info.CPU.Processors != 2 || info.Memory != 1024 {
+		t.Fatalf("The clone hasn't the settings of the parent: %d %#v", status, info)
+	}
+	clone, ok := s.VM(info.ID)
+	if !ok || clone.DisplayName != "clone" || clone.Path != "/vmware/clone/clone.vmx" || len(clone.NICs) != 1 {
+		t.Errorf("The server hasn't stored the clone: %#v", clone)
+	}
+	var vmerr vmrestError
+	status = call(t, s, "POST", "vms", `{"name":"orphan","parentId":"MISSING"}`, &vmerr)
+	if status != http.StatusNotFound || vmerr.Code != CodeNotFound {
+		t.Errorf("Clone a missing parent should be a not found error: %d %#v", status, vmerr)
+	}
+	call(t, s, "PUT", "vms/"+info.ID+"/power", "on", nil)
+	status = call(t, s, "DELETE", "vms/"+info.ID, "", &vmerr)
+	if status != http.StatusConflict || vmerr.Code != CodeInvalidState {
+		t.Errorf("Delete a running VM should be a conflict: %d %#v", status, vmerr)
+	}
+	call(t, s, "PUT", "vms/"+info.ID+"/power", "off", nil)
+	status = call(t, s, "DELETE", "vms/"+info.ID, "", nil)
+	if status != http.StatusNoContent || len(s.VMs()) != 1 {
+		t.Errorf("The VM hasn't been deleted: %d %#v", status, s.VMs())
+	}
+	if s.CountRequests("DELETE", "vms/"+info.ID) != 2 {
+		t.Errorf("The server hasn't recorded the requests: %#v", s.Requests())
+	}
+}
+
+func TestServerPower(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	steps := []struct {
+		operation string
+		status    int
+		state     string
+	}{
+		{"pause", http.StatusConflict, PoweredOff},
+		{"on", http.StatusOK, PoweredOn},
+		{"pause", http.StatusOK, Paused},
+		{"unpause", http.StatusOK, PoweredOn},
+		{"suspend", http.StatusOK, Suspended},
+		{"on", http.StatusOK, PoweredOn},
+		{"shutdown", http.StatusOK, PoweredOff},
+		{"explode", http.StatusBadRequest, PoweredOff},
+	}
+	for _, step := range steps {
+		status := call(t, s, "PUT", "vms/VMID/power", step.operation, nil)
+		vm, _ := s.VM("VMID")
+		if status != step.status || vm.PowerState != step.state {
+			t.Errorf("After %s we expected %d %s, we have %d %s", step.operation, step.status, step.state, status, vm.PowerState)
+		}
+	}
+}
+
+func TestServerSlowShutdown(t *testing.T) {
+	s := NewServer(VM{ID: "SLOW", PowerState: PoweredOn, ShutdownPolls: 2}, VM{ID: "DEAF", PowerState: PoweredOn, ShutdownPolls: -1})
+	defer s.Close()
+	var power powerPayload
+	call(t, s, "PUT", "vms/SLOW/power", "shutdown", &power)
+	for _, want := range []string{PoweredOn, PoweredOn, PoweredOff} {
+		if power.Value != want {
+			t.Errorf("The guest should be %s, it's %s", want, power.Value)
+		}
+		call(t, s, "GET", "vms/SLOW/power", "", &power)
+	}
+	call(t, s, "PUT", "vms/DEAF/power", "shutdown", &power)
+	call(t, s, "GET", "vms/DEAF/power", "", &power)
+	if power.Value != PoweredOn {
+		t.Errorf("The guest should ignore the shutdown: %#v", power)
+	}
+	call(t, s, "PUT", "vms/DEAF/power", "off", &power)
+	if power.Value != PoweredOff {
+		t.Errorf("The power off should be immediate: %#v", power)
+	}
+}
+
+func TestServerIP(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	if status := call(t, s, "GET", "vms/VMID/ip", "", nil); status != http.StatusConflict {
+		t.Errorf("The VM is off, the status should be 409, we have %d", status)
+	}
+	s.SetPowerState("VMID", PoweredOn)
+	if status := call(t, s, "GET", "vms/VMID/ip", "", nil); status != http.StatusInternalServerError {
+		t.Errorf("The guest hasn't an IP, the status should be 500, we have %d", status)
+	}
+	s.SetIP("VMID", "192.168.1.10")
+	var ip ipPayload
+	call(t, s, "GET", "vms/VMID/ip", "", &ip)
+	if ip.IP != "192.168.1.10" {
+		t.Errorf("We haven't got the IP: %#v", ip)
+	}
+	var network nicIPsPayload
+	call(t, s, "GET", "vms/VMID/nicips", "", &network)
+	if network.DNS.Server[0] != "192.168.1.1" || network.Routes[0].Nexthop != "192.168.1.1" {
+		t.Errorf("We haven't got the network of the guest: %#v", network)
+	}
+}
+
+func TestServerNICsAndParams(t *testing.T) {
+	s := NewServer(VM{ID: "VMID"})
+	defer s.Close()
+	var nic NIC
+	call(t, s, "POST", "vms/VMID/nic", `{"type":"nat","vmnet":"vmnet8"}`, &nic)
+	if nic.Index != 1 || nic.MacAddress == "" {
+		t.Errorf("The NIC hasn't been created: %#v", nic)
+	}
+	status := call(t, s, "DELETE", "vms/VMID/nic/7", "", nil)
+	if status != http.StatusNotFound {
+		t.Errorf("Delete a missing NIC should be not found, we have %d", status)
+	}
+	call(t, s, "PUT", "vms/VMID/configparams", `{"name":"annotation","value":"Description"}`, nil)
+	var param paramPayload
+	call(t, s, "GET", "vms/VMID/params/annotation", "", &param)
+	if param.Value != "Description" {
+		t.Errorf("The parameter hasn't been changed: %#v", param)
+	}
+}
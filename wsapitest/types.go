@@ -0,0 +1,197 @@
+package wsapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// These are the codes of the VmError that the fake server give us, they follow
+// the same idea that vmrest, one code for each kind of failure.
+const (
+	CodeAuthentication = 100
+	CodeInvalidRequest = 101
+	CodeNotFound       = 104
+	CodeInvalidState   = 106
+	CodeAlreadyExists  = 107
+	CodeInUse          = 108
+)
+
+// The Power States that vmrest give us in the power_state field.
+const (
+	PoweredOn  = "poweredOn"
+	PoweredOff = "poweredOff"
+	Suspended  = "suspended"
+	Paused     = "paused"
+)
+
+// VM is the in memory model of a VM of the fake server.
+// ID: (string) The ID of the VM, if it's empty the server generate one.
+// Path: (string) The path of the .vmx file, if it's empty the server generate one.
+// DisplayName: (string) The denomination of the VM.
+// Annotation: (string) The description of the VM.
+// PowerState: (string) One of PoweredOn, PoweredOff, Suspended or Paused, by default PoweredOff.
+// Processors: (int32) The number of processors.
+// Memory: (int32) The size of the memory in MB.
+// NICs: ([]NIC) The network adapters of the VM.
+// Params: (map[string]string) The rest of the parameters of the .vmx file.
+// IP: (string) The IP that the guest report when the VM is powered on.
+// ShutdownPolls: (int) Reads of the power state that the guest takes to finish a shutdown,
+// 0 means that it's immediate and a negative number that the guest never does it, like without VMware Tools.
+type VM struct {
+	ID            string
+	Path          string
+	DisplayName   string
+	Annotation    string
+	PowerState    string
+	Processors    int32
+	Memory        int32
+	NICs          []NIC
+	Params        map[string]string
+	IP            string
+	ShutdownPolls int
+	// shutdownLeft are the reads of the power state until the guest is off, 0 if it isn't shutting down
+	shutdownLeft int
+}
+
+// NIC is the in memory model of a network adapter of the fake server.
+type NIC struct {
+	Index      int32  `json:"index"`
+	Type       string `json:"type"`
+	Vmnet      string `json:"vmnet"`
+	MacAddress string `json:"macAddress"`
+}
+
+// Request is the record of an API call that the fake server has received.
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// Server is a fake vmrest server that keep the VMs in memory, it's made with
+// httptest.Server so we can use it in hermetic tests.
+// URL: (string) The base URL of the API, like https://127.0.0.1:1234/api.
+// User: (string) The user that the server accept.
+// Password: (string) The password that the server accept.
+type Server struct {
+	URL      string
+	User     string
+	Password string
+	server   *httptest.Server
+	handler  http.Handler
+	mu       sync.Mutex
+	vms      map[string]*VM
+	order    []string
+	nextID   int
+	nextMAC  int
+	requests []Request
+	faults   []*Fault
+}
+
+// vmrestError is the body of the errors, the same that httpclient.VmError.
+type vmrestError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// vmPayload is the body of the list of VMs and of the registration.
+type vmPayload struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// vmInfoPayload is the body of the information of one VM.
+type vmInfoPayload struct {
+	ID  string `json:"id"`
+	CPU struct {
+		Processors int32 `json:"processors"`
+	} `json:"cpu"`
+	Memory int32 `json:"memory"`
+}
+
+// settingPayload is the body to change the processors and the memory.
+type settingPayload struct {
+	Processors int32 `json:"processors"`
+	Memory     int32 `json:"memory"`
+}
+
+// clonePayload is the body to clone a VM.
+type clonePayload struct {
+	Name     string `json:"name"`
+	ParentID string `json:"parentId"`
+}
+
+// registerPayload is the body to register a VM.
+type registerPayload struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// paramPayload is the body of the parameters of a VM.
+type paramPayload struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// powerPayload is the body of the power state of a VM.
+type powerPayload struct {
+	Value string `json:"power_state"`
+}
+
+// ipPayload is the body of the IP of a VM.
+type ipPayload struct {
+	IP string `json:"ip"`
+}
+
+// nicIPsPayload is the body of the network information of the guest, we only fill the values that we simulate.
+type nicIPsPayload struct {
+	NICs   []guestNICPayload   `json:"nics"`
+	Routes []guestRoutePayload `json:"routes"`
+	DNS    guestDNSPayload     `json:"dns"`
+	DHCPv4 guestDHCPPayload    `json:"dhcpv4"`
+}
+
+// guestNICPayload is a NIC as the guest see it.
+type guestNICPayload struct {
+	Mac   string           `json:"mac"`
+	IP    []string         `json:"ip"`
+	DNS   guestDNSPayload  `json:"dns"`
+	DHCP4 guestDHCPPayload `json:"dhcp4"`
+}
+
+// guestDNSPayload are the DNS settings of the guest.
+type guestDNSPayload struct {
+	Hostname   string   `json:"hostname"`
+	Domainname string   `json:"domainname"`
+	Server     []string `json:"server"`
+	Search     []string `json:"search"`
+}
+
+// guestDHCPPayload says if the guest use DHCP.
+type guestDHCPPayload struct {
+	Enabled  bool   `json:"enabled"`
+	Settings string `json:"settings"`
+}
+
+// guestRoutePayload is a route of the guest.
+type guestRoutePayload struct {
+	Dest      string `json:"dest"`
+	Prefix    int32  `json:"prefix"`
+	Nexthop   string `json:"nexthop"`
+	Interface int32  `json:"interface"`
+	Type      int32  `json:"type"`
+	Metric    int32  `json:"metric"`
+}
+
+// nicPayload is the body to create or update a NIC.
+type nicPayload struct {
+	Type  string `json:"type"`
+	Vmnet string `json:"vmnet"`
+}
+
+// nicsPayload is the body of the list of NICs.
+type nicsPayload struct {
+	Num  int   `json:"num"`
+	NICS []NIC `json:"nics"`
+}
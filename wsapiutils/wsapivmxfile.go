@@ -4,12 +4,12 @@ import (
 	"os"
 
 	"github.com/elsudano/govmx"
+	"github.com/elsudano/vmware-workstation-api-client/internal/redact"
 	"github.com/rs/zerolog/log"
 )
 
 func New() VMFile {
-	var vmx *govmx.VirtualMachine
-	return &VMStructure{myvm: vmx}
+	return &VMStructure{myvm: new(govmx.VirtualMachine)}
 }
 
 // GetVMFromFile - With this function we can obtain a vmx.VirtualMachine structure
@@ -25,13 +25,13 @@ func (vmf *VMStructure) GetVMFromFile(f string) error {
 		log.Error().Err(err).Msg("Please make sure the config file exists.")
 		return err
 	}
-	log.Debug().Msgf("After read the file: %#v", data)
+	log.Debug().Msgf("After read the file: %#v", redact.String(string(data)))
 	err = govmx.Unmarshal(data, vmf.myvm)
 	if err != nil {
 		log.Error().Err(err).Msg("Error trying to Unmarshal the data.")
 		return err
 	}
-	log.Debug().Msgf("After Unmarshal the data: %#v", vmf.myvm)
+	log.Debug().Msgf("After Unmarshal the data, the VM is: %#v", vmf.myvm.DisplayName)
 	log.Info().Msg("We have read the VM file and load the data in the vmx object.")
 	return nil
 }
@@ -51,13 +51,13 @@ func (vmf *VMStructure) SetVMToFile(f string) error {
 		log.Error().Err(err).Msg("Error trying to Unmarshal the data.")
 		return err
 	}
-	log.Debug().Msgf("After Marshaling the data VM is: %#v", vmf.myvm)
+	log.Debug().Msgf("After Marshaling the data VM is: %#v", redact.String(string(data)))
 	err = os.WriteFile(f, data, 0644)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error writing in file %#v, please make sure the file exists.", f)
 		return err
 	}
-	log.Debug().Msgf("We have saved the VM %#v in the file: %#v", vmf.myvm, f)
+	log.Debug().Msgf("We have saved the VM %#v in the file: %#v", vmf.myvm.DisplayName, f)
 	log.Info().Msg("We have saved the VM in a file.")
 	return err
 }
@@ -1,6 +1,10 @@
 package wsapiutils
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestGetVMFromFile(t *testing.T) {
 
@@ -21,5 +25,21 @@ func TestSetDisplayName(t *testing.T) {
 
 }
 func TestSetDenominationDescription(t *testing.T) {
-
+	f := filepath.Join(t.TempDir(), "test.vmx")
+	err := os.WriteFile(f, []byte("displayName = \"test\"\nannotation = \"The test VM\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = New().SetDenominationDescription(f, "renamed", "The renamed VM")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	n, err := New().GetDisplayName(f)
+	if err != nil || n != "renamed" {
+		t.Errorf("We haven't changed the Denomination: %#v %#v", n, err)
+	}
+	d, err := New().GetAnnotation(f)
+	if err != nil || d != "The renamed VM" {
+		t.Errorf("We haven't changed the Description: %#v %#v", d, err)
+	}
 }
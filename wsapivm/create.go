@@ -0,0 +1,250 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// These are the types of NIC that the API accept.
+const (
+	NICBridged  = "bridged"
+	NICNat      = "nat"
+	NICHostOnly = "hostonly"
+	NICCustom   = "custom"
+)
+
+// ErrInvalidSpec the specification of the VM that we want to create isn't valid.
+var ErrInvalidSpec = errors.New("invalid VM specification")
+
+// NICSpec is the definition of a NIC that we want in a new VM.
+// Type: (string) One of NICBridged, NICNat, NICHostOnly or NICCustom.
+// Vmnet: (string) The virtual network, like vmnet2, we need it with NICCustom.
+type NICSpec struct {
+	Type  string `json:"type"`
+	Vmnet string `json:"vmnet"`
+}
+
+// CreateVMSpec is the definition of the VM that we want to create, we clone the parent
+// VM and then we change the clone until it has what the specification says.
+// ParentID: (string) The ID of the parent VM, we need it or ParentName, not both.
+// ParentName: (string) The denomination of the parent VM, it can't be ambiguous.
+// DisplayName: (string) The denomination of the new VM.
+// Description: (string) The description of the new VM, empty means the description of the parent.
+// CPUs: (int32) The number of processors, 0 means the same as the parent.
+// Memory: (int32) The memory in MB, multiple of 4, 0 means the same as the parent.
+// PowerState: (PowerState) PowerStateOn or PowerStateOff, empty means off like the clone.
+// NICs: ([]NICSpec) The NICs of the new VM, empty means the NICs of the parent with new MAC addresses.
+// ConfigParams: (map[string]string) Extra parameters of the .vmx file.
+// WaitForIP: (*WaitOptions) If it isn't nil we wait until the guest has an IP, it needs PowerStateOn.
+type CreateVMSpec struct {
+	ParentID     string
+	ParentName   string
+	DisplayName  string
+	Description  string
+	CPUs         int32
+	Memory       int32
+	PowerState   PowerState
+	NICs         []NICSpec
+	ConfigParams map[string]string
+	WaitForIP    *WaitOptions
+}
+
+// SpecError is the error of one field of a CreateVMSpec, errors.Is(err, ErrInvalidSpec) is true.
+// Field: (string) The name of the field, like Memory or NICs[1].Type.
+// Reason: (string) What is wrong with the field.
+type SpecError struct {
+	Field  string
+	Reason string
+}
+
+// Error method to implement the error interface.
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Reason)
+}
+
+// Is method allow to use errors.Is with ErrInvalidSpec.
+func (e *SpecError) Is(target error) bool {
+	return target == ErrInvalidSpec
+}
+
+// Validate method check the specification without any API call.
+// Output:
+// error: (error) nil if the specification is valid, if not all the *SpecError that we have found joined.
+func (spec CreateVMSpec) Validate() error {
+	var errs []error
+	invalid := func(field string, format string, args ...any) {
+		errs = append(errs, &SpecError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+	switch {
+	case spec.ParentID == "" && spec.ParentName == "":
+		invalid("ParentID", "is empty, we need the ID or the name of the parent VM")
+	case spec.ParentID != "" && spec.ParentName != "":
+		invalid("ParentID", "can't be used with ParentName")
+	}
+	if strings.TrimSpace(spec.DisplayName) == "" {
+		invalid("DisplayName", "is empty")
+	}
+	if spec.CPUs < 0 {
+		invalid("CPUs", "can't be negative: %d", spec.CPUs)
+	}
+	if spec.Memory < 0 || spec.Memory%4 != 0 {
+		invalid("Memory", "has to be 0 or a positive multiple of 4 MB: %d", spec.Memory)
+	}
+	switch spec.PowerState {
+	case "", PowerStateOn, PowerStateOff:
+	default:
+		invalid("PowerState", "has to be %q or %q: %q", PowerStateOn, PowerStateOff, spec.PowerState)
+	}
+	errs = append(errs, validateNICs(spec.NICs)...)
+	for name := range spec.ConfigParams {
+		if strings.TrimSpace(name) == "" {
+			invalid("ConfigParams", "has a parameter without name")
+		}
+	}
+	if spec.WaitForIP != nil && spec.PowerState != PowerStateOn {
+		invalid("WaitForIP", "needs the PowerState %q", PowerStateOn)
+	}
+	return errors.Join(errs...)
+}
+
+// validateNICs function check the NICs of a specification, it return a *SpecError for each problem.
+func validateNICs(nics []NICSpec) []error {
+	var errs []error
+	for i, nic := range nics {
+		if !slices.Contains([]string{NICBridged, NICNat, NICHostOnly, NICCustom}, nic.Type) {
+			errs = append(errs, &SpecError{Field: fmt.Sprintf("NICs[%d].Type", i), Reason: fmt.Sprintf("isn't a type of NIC: %q", nic.Type)})
+		}
+		if nic.Type == NICCustom && nic.Vmnet == "" {
+			errs = append(errs, &SpecError{Field: fmt.Sprintf("NICs[%d].Vmnet", i), Reason: fmt.Sprintf("is empty, we need it with a %s NIC", NICCustom)})
+		}
+	}
+	return errs
+}
+
+// Create method to create a new VM with the specification, we validate it before any API call.
+// Input:
+// spec: (CreateVMSpec) The definition of the new VM.
+// Output:
+// (*MyVm) The new VM, nil if we couldn't create it, if the rollback is disabled we return the clone with the error.
+// error: (error) An error with ErrInvalidSpec if the specification isn't valid, a *RollbackError if something
+// goes wrong after the clone, or the error of the API.
+func (vmm *VMManager) Create(spec CreateVMSpec) (*MyVm, error) {
+	return vmm.CreateContext(context.Background(), spec)
+}
+
+// CreateContext is the same as Create but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) CreateContext(ctx context.Context, spec CreateVMSpec) (*MyVm, error) {
+	err := spec.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("create VM %q: %w", spec.DisplayName, err)
+	}
+	defer vmm.invalidate()
+	pid := spec.ParentID
+	if spec.ParentName != "" {
+		parent, err := vmm.findVM(ctx, keyName, spec.ParentName)
+		if err != nil {
+			return nil, fmt.Errorf("create VM %q: parent: %w", spec.DisplayName, err)
+		}
+		pid = parent.IdVM
+	}
+	vm, err := CloneVMContext(ctx, vmm.vmclient, pid, spec.DisplayName)
+	if err != nil {
+		return nil, fmt.Errorf("create VM %q: %w", spec.DisplayName, err)
+	}
+	vmm.log().Debug().Msgf("The Clone VM is: %#v", vm)
+	vm.Denomination = spec.DisplayName
+	err = vmm.configure(ctx, vm, spec)
+	if err != nil && vmm.keepFailed {
+		vmm.log().Warn().Err(err).Msgf("We keep the VM %#v that we couldn't create because the rollback is disabled.", vm.IdVM)
+		return vm, fmt.Errorf("create VM %q: %w", spec.DisplayName, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create VM %q: %w", spec.DisplayName, &RollbackError{IdVM: vm.IdVM, Err: err, CleanupErr: vmm.rollback(ctx, vm)})
+	}
+	vmm.log().Info().Msg("We have created the VM.")
+	return vm, nil
+}
+
+// rollback method delete the clone that we couldn't configure, we cut the power because
+// there isn't anything to save in it. We use a new deadline because the context of the
+// creation can be the reason of the failure.
+func (vmm *VMManager) rollback(ctx context.Context, vm *MyVm) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultRollbackTimeout)
+	defer cancel()
+	err := GetPowerStatusContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return fmt.Errorf("delete VM %q: %w", vm.IdVM, err)
+	}
+	if PowerState(vm.PowerStatus) != PowerStateOff {
+		err = vmm.hardStop(ctx, vm)
+		if err != nil {
+			return err
+		}
+	}
+	err = vmm.remove(ctx, vm)
+	if err != nil {
+		vmm.log().Error().Err(err).Msgf("We couldn't delete the VM %#v that we couldn't create.", vm.IdVM)
+		return err
+	}
+	vmm.log().Info().Msgf("We have deleted the VM %#v that we couldn't create.", vm.IdVM)
+	return nil
+}
+
+// configure method change the clone until it has what the specification says.
+func (vmm *VMManager) configure(ctx context.Context, vm *MyVm, spec CreateVMSpec) error {
+	if spec.CPUs > 0 || spec.Memory > 0 {
+		p, m := spec.CPUs, spec.Memory
+		if p == 0 {
+			p = vm.CPU.Processors
+		}
+		if m == 0 {
+			m = vm.Memory
+		}
+		err := SetBasicInfoContext(ctx, vmm.vmclient, vm, p, m)
+		if err != nil {
+			return err
+		}
+		vmm.log().Debug().Msgf("We have put %#v processors and %#v memory in %#v VM", p, m, vm.Denomination)
+	}
+	// The API only use the name for the files of the clone, so we put the denomination again
+	err := vmm.createMetadata(ctx, vm, spec)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(spec.ConfigParams))
+	for name := range spec.ConfigParams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		err := SetParameterContext(ctx, vmm.vmclient, vm, name, spec.ConfigParams[name])
+		if err != nil {
+			return err
+		}
+	}
+	// The clone has the same MAC address that the parent, so we always create the NICs again
+	err = SetNICsContext(ctx, vmm.vmclient, vm, spec.NICs)
+	if err != nil {
+		return err
+	}
+	if spec.PowerState != PowerStateOn {
+		vm.PowerStatus = string(PowerStateOff)
+		return nil
+	}
+	err = PowerOperateContext(ctx, vmm.vmclient, vm, PowerOperationOn)
+	if err != nil {
+		return err
+	}
+	vmm.log().Debug().Msgf("We have Changed the state of VM to: %#v", spec.PowerState)
+	if spec.WaitForIP != nil {
+		vm.IP, err = vmm.WaitForIP(ctx, vm, *spec.WaitForIP)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestCreateVMSpecValidate(t *testing.T) {
+	valid := CreateVMSpec{ParentID: "PARENT", DisplayName: "clone"}
+	tests := []struct {
+		name   string
+		change func(spec *CreateVMSpec)
+		fields []string
+	}{
+		{"valid", func(spec *CreateVMSpec) {}, nil},
+		{"without parent", func(spec *CreateVMSpec) { spec.ParentID = "" }, []string{"ParentID"}},
+		{"both parents", func(spec *CreateVMSpec) { spec.ParentName = "parent" }, []string{"ParentID"}},
+		{"without name", func(spec *CreateVMSpec) { spec.DisplayName = " " }, []string{"DisplayName"}},
+		{"bad settings", func(spec *CreateVMSpec) { spec.CPUs = -1; spec.Memory = 1023 }, []string{"CPUs", "Memory"}},
+		{"bad power state", func(spec *CreateVMSpec) { spec.PowerState = PowerStateSuspended }, []string{"PowerState"}},
+		{"bad NICs", func(spec *CreateVMSpec) { spec.NICs = []NICSpec{{Type: "nat"}, {Type: "wifi"}, {Type: NICCustom}} }, []string{"NICs[1].Type", "NICs[2].Vmnet"}},
+		{"param without name", func(spec *CreateVMSpec) { spec.ConfigParams = map[string]string{"": "TRUE"} }, []string{"ConfigParams"}},
+		{"wait for IP when off", func(spec *CreateVMSpec) { spec.WaitForIP = &fastWait }, []string{"WaitForIP"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := valid
+			test.change(&spec)
+			err := spec.Validate()
+			if len(test.fields) == 0 {
+				if err != nil {
+					t.Fatalf("The specification should be valid: %#v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSpec) {
+				t.Fatalf("The error should be ErrInvalidSpec: %#v", err)
+			}
+			var fields []string
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var specErr *SpecError
+				if errors.As(err, &specErr) {
+					fields = append(fields, specErr.Field)
+				}
+			}
+			if len(fields) != len(test.fields) {
+				t.Fatalf("We want the errors of %v, we have %v", test.fields, fields)
+			}
+			for i := range fields {
+				if fields[i] != test.fields[i] {
+					t.Errorf("We want the errors of %v, we have %v", test.fields, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	parent := parentVM
+	parent.IP = "192.168.1.20"
+	vmc, server := newTestClient(t, parent)
+	vm, err := New(vmc).Create(CreateVMSpec{
+		ParentName:   "parent",
+		DisplayName:  "clone",
+		Description:  "The clone",
+		CPUs:         4,
+		PowerState:   PowerStateOn,
+		NICs:         []NICSpec{{Type: NICBridged}, {Type: NICCustom, Vmnet: "vmnet2"}},
+		ConfigParams: map[string]string{"tools.syncTime": "TRUE"},
+		WaitForIP:    &fastWait,
+	})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, ok := server.VM(vm.IdVM)
+	if !ok || stored.DisplayName != "clone" || stored.Annotation != "The clone" || stored.Processors != 4 || stored.Memory != 2048 {
+		t.Fatalf("The VM hasn't been created with the settings: %#v", stored)
+	}
+	if stored.Params["tools.syncTime"] != "TRUE" || stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("The VM hasn't the parameters or the Power State: %#v", stored)
+	}
+	if len(stored.NICs) != 2 || stored.NICs[0].Type != NICBridged || stored.NICs[1].Vmnet != "vmnet2" {
+		t.Errorf("The VM hasn't the NICs of the specification: %#v", stored.NICs)
+	}
+	if vm.IP != "192.168.1.20" || vm.Description != "The clone" || vm.PowerStatus != "on" {
+		t.Errorf("The VM that we receive isn't updated: %#v", vm)
+	}
+}
+
+func TestCreateRenewMAC(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vm, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM(vm.IdVM)
+	if len(stored.NICs) != 1 || stored.NICs[0].Type != "nat" || stored.NICs[0].Vmnet != "vmnet8" {
+		t.Fatalf("The VM should have the NICs of the parent: %#v", stored.NICs)
+	}
+	if stored.NICs[0].MacAddress == parentVM.NICs[0].MacAddress {
+		t.Errorf("The NIC of the VM should have a new MAC address: %#v", stored.NICs)
+	}
+	if stored.PowerState != wsapitest.PoweredOff || stored.Processors != 2 {
+		t.Errorf("The VM should be off with the settings of the parent: %#v", stored)
+	}
+}
+
+func TestCreateWithoutAPICalls(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	_, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", Memory: 1000})
+	if !errors.Is(err, ErrInvalidSpec) {
+		t.Fatalf("The error should be ErrInvalidSpec: %#v", err)
+	}
+	if len(server.Requests()) != 0 {
+		t.Errorf("We shouldn't call the API with an invalid specification: %#v", server.Requests())
+	}
+	_, err = New(vmc).Create(CreateVMSpec{ParentName: "missing", DisplayName: "clone"})
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+}
+
+func TestCreateRollback(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vm, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone", PowerState: PowerStateOn, WaitForIP: &fastWait})
+	var rollback *RollbackError
+	if !errors.As(err, &rollback) || rollback.CleanupErr != nil || vm != nil {
+		t.Fatalf("The error should be a RollbackError without cleanup error: %#v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("We should see the original error: %#v", err)
+	}
+	if _, ok := server.VM(rollback.IdVM); ok {
+		t.Errorf("We should have deleted the clone %q", rollback.IdVM)
+	}
+	if server.CountRequests("PUT", "vms/"+rollback.IdVM+"/power") != 2 {
+		t.Errorf("We should have cut the power of the clone before delete it: %#v", server.Requests())
+	}
+}
+
+func TestCreateRollbackFailure(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	server.On("POST", "vms/*/nic").Fail(http.StatusInternalServerError, 0, "Internal error")
+	server.On("DELETE", "vms/*").Busy()
+	_, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone"})
+	var rollback *RollbackError
+	if !errors.As(err, &rollback) || rollback.CleanupErr == nil {
+		t.Fatalf("The error should be a RollbackError with cleanup error: %#v", err)
+	}
+	var apiErr *httpclient.APIError
+	if !errors.As(rollback.Err, &apiErr) || apiErr.Method != "POST" || !errors.Is(err, httpclient.ErrVMBusy) {
+		t.Errorf("We should see the original error and the error of the cleanup: %#v", err)
+	}
+	if _, ok := server.VM(rollback.IdVM); !ok {
+		t.Errorf("The clone %q should be there because we couldn't delete it", rollback.IdVM)
+	}
+}
+
+func TestCreateWithoutRollback(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	server.On("POST", "vms/*/nic").Fail(http.StatusInternalServerError, 0, "Internal error")
+	vm, err := New(vmc, WithoutRollback()).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone"})
+	var rollback *RollbackError
+	if err == nil || errors.As(err, &rollback) || vm == nil {
+		t.Fatalf("We want the clone with the original error: %#v %#v", vm, err)
+	}
+	if _, ok := server.VM(vm.IdVM); !ok || server.CountRequests("DELETE", "vms/"+vm.IdVM) != 0 {
+		t.Errorf("We shouldn't delete the clone %q", vm.IdVM)
+	}
+}
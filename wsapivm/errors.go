@@ -0,0 +1,159 @@
+package wsapivm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+)
+
+// ErrAmbiguousVM we look for a VM with a key that more than one VM has.
+var ErrAmbiguousVM = errors.New("more than one VM match")
+
+// ErrInvalidState the Power State of the VM doesn't allow the operation without lose its state.
+var ErrInvalidState = errors.New("invalid power state for the operation")
+
+// VMNotFoundError is the error that we have when there isn't any VM with the key that we look for,
+// errors.Is(err, httpclient.ErrNotFound) is true, like when the API doesn't find it.
+// Key: (string) The kind of key that we have used, id, name or path.
+// Value: (string) The value of the key.
+type VMNotFoundError struct {
+	Key   string
+	Value string
+}
+
+// Error method to implement the error interface.
+func (e *VMNotFoundError) Error() string {
+	return fmt.Sprintf("there isn't any VM with the %s %q", e.Key, e.Value)
+}
+
+// Is method allow to use errors.Is with httpclient.ErrNotFound.
+func (e *VMNotFoundError) Is(target error) bool {
+	return target == httpclient.ErrNotFound
+}
+
+// AmbiguousVMError is the error that we have when more than one VM has the key that we
+// look for, errors.Is(err, ErrAmbiguousVM) is true.
+// Key: (string) The kind of key that we have used, like name.
+// Value: (string) The value of the key.
+// Candidates: ([]MyVm) All the VMs that have this key, with their ID and Path.
+type AmbiguousVMError struct {
+	Key        string
+	Value      string
+	Candidates []MyVm
+}
+
+// Error method to implement the error interface, it says the ID and the Path of all the candidates.
+func (e *AmbiguousVMError) Error() string {
+	candidates := make([]string, 0, len(e.Candidates))
+	for _, vm := range e.Candidates {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", vm.IdVM, vm.Path))
+	}
+	return fmt.Sprintf("there are %d VMs with the %s %q: %s", len(e.Candidates), e.Key, e.Value, strings.Join(candidates, ", "))
+}
+
+// Is method allow to use errors.Is with ErrAmbiguousVM.
+func (e *AmbiguousVMError) Is(target error) bool {
+	return target == ErrAmbiguousVM
+}
+
+// VMError is the error of one VM in an operation with many VMs.
+// IdVM: (string) The ID of the VM.
+// Err: (error) The error that we have had with this VM.
+type VMError struct {
+	IdVM string
+	Err  error
+}
+
+// Error method to implement the error interface.
+func (e *VMError) Error() string {
+	return fmt.Sprintf("VM %q: %s", e.IdVM, e.Err)
+}
+
+// Unwrap method return the original error.
+func (e *VMError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError is the error that GetAllVMs give us when we couldn't load some VMs, the
+// list that we receive with it has the rest of the VMs. errors.Is and errors.As look
+// inside the errors of all the VMs.
+// Total: (int) The number of VMs that we have tried to load.
+// Errors: ([]*VMError) The errors of the VMs that we couldn't load.
+type PartialError struct {
+	Total  int
+	Errors []*VMError
+}
+
+// Error method to implement the error interface.
+func (e *PartialError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("we couldn't load %d of %d VMs: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap method return the errors of all the VMs.
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// RollbackError is the error that Create and CreateVM give us when something goes wrong
+// after the clone, we have tried to delete the clone and it says if we could do it.
+// errors.Is and errors.As look inside the original error and the error of the cleanup.
+// IdVM: (string) The ID of the clone.
+// Err: (error) The error that we had creating the VM.
+// CleanupErr: (error) The error that we had deleting the clone, nil if we have deleted it.
+type RollbackError struct {
+	IdVM       string
+	Err        error
+	CleanupErr error
+}
+
+// Error method to implement the error interface.
+func (e *RollbackError) Error() string {
+	if e.CleanupErr == nil {
+		return fmt.Sprintf("%s, we have deleted the clone %q", e.Err, e.IdVM)
+	}
+	return fmt.Sprintf("%s, and we couldn't delete the clone %q: %s", e.Err, e.IdVM, e.CleanupErr)
+}
+
+// Unwrap method return the original error and the error of the cleanup if we have it.
+func (e *RollbackError) Unwrap() []error {
+	if e.CleanupErr == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.CleanupErr}
+}
+
+// ErrDrift the VM has changed since we made the plan.
+var ErrDrift = errors.New("the VM has changed since the plan")
+
+// DriftError is the error that ApplyPlan give us when the VM isn't like when we made the
+// plan, errors.Is(err, ErrDrift) is true.
+// IdVM: (string) The ID of the VM.
+// Drifts: ([]Change) The fields that have changed, Old is the value of the plan and New the current value.
+type DriftError struct {
+	IdVM   string
+	Drifts []Change
+}
+
+// Error method to implement the error interface.
+func (e *DriftError) Error() string {
+	drifts := make([]string, 0, len(e.Drifts))
+	for _, drift := range e.Drifts {
+		drifts = append(drifts, fmt.Sprintf("%s was %q and now is %q", drift.Field, drift.Old, drift.New))
+	}
+	return fmt.Sprintf("the VM %q has changed since the plan: %s", e.IdVM, strings.Join(drifts, ", "))
+}
+
+// Is method allow to use errors.Is with ErrDrift.
+func (e *DriftError) Is(target error) bool {
+	return target == ErrDrift
+}
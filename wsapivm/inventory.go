@@ -0,0 +1,203 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+)
+
+// These are the keys that we can use to look for a VM.
+const (
+	keyID   = "id"
+	keyName = "name"
+	keyPath = "path"
+)
+
+// inventory is the cache of the IDs, the paths and the names of the VMs, with it we
+// don't need to download the list of VMs and the name of each one in every lookup.
+// ttl: (time.Duration) Time that the cache is valid after we load it.
+// loaded: (time.Time) When we have loaded the cache, zero if it's empty.
+// generation: (int) It change in each invalidation, so a refresh that starts before
+// an invalidation doesn't keep old data.
+// paths: (map[string]string) The path of each ID.
+// ids: (map[string]string) The ID of each path.
+// names: (map[string][]string) The IDs of each name, more than one if the name is repeated.
+// unnamed: (int) The number of VMs that we couldn't read its name, they aren't in names.
+type inventory struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	loaded     time.Time
+	generation int
+	paths      map[string]string
+	ids        map[string]string
+	names      map[string][]string
+	unnamed    int
+}
+
+// WithInventoryCache option enable the cache of the IDs, the paths and the names of
+// the VMs that LoadVM, LoadVMbyName and LoadVMbyPath use. CreateVM, UpdateVM, DeleteVM
+// and RegisterVM clean the cache, and Refresh load it again.
+// Inputs:
+// ttl: (time.Duration) Time that the cache is valid, 0 or less means that we don't use the cache.
+func WithInventoryCache(ttl time.Duration) Option {
+	return func(vmm *VMManager) {
+		vmm.SetInventoryCache(ttl)
+	}
+}
+
+// SetInventoryCache method enable or disable the cache of the IDs, the paths and the names of the VMs.
+// Inputs:
+// ttl: (time.Duration) Time that the cache is valid, 0 or less means that we don't use the cache.
+func (vmm *VMManager) SetInventoryCache(ttl time.Duration) {
+	if ttl <= 0 {
+		vmm.cache = nil
+		return
+	}
+	vmm.cache = &inventory{ttl: ttl}
+}
+
+// Refresh method load the cache of the VMs again, it does nothing if we don't use the cache.
+// If we can't read the name of a VM we keep it in the cache without name, so we can still
+// find it by ID or path, and we look for the VMs by name in the API.
+// Outputs:
+// error: (error) The possible error that you will have.
+func (vmm *VMManager) Refresh() error {
+	return vmm.RefreshContext(context.Background())
+}
+
+// RefreshContext is the same as Refresh but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) RefreshContext(ctx context.Context) error {
+	if vmm.cache == nil {
+		return nil
+	}
+	vmm.cache.mu.Lock()
+	generation := vmm.cache.generation
+	vmm.cache.mu.Unlock()
+	vms, err := listVMs(ctx, vmm.vmclient)
+	if err != nil {
+		return fmt.Errorf("refresh the inventory: %w", err)
+	}
+	errs := vmm.forEach(ctx, vms, func(ctx context.Context, vm *MyVm) error {
+		name, err := GetParameterContext(ctx, vmm.vmclient, vm, "displayName")
+		vm.Denomination = name
+		return err
+	})
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("refresh the inventory: %w", err)
+	}
+	for pos, vm := range vms {
+		if err, failed := errs[pos]; failed {
+			vmm.log().Warn().Msgf("We can't read the name of the VM %#v, we keep it in the inventory without name: %v", vm.IdVM, err)
+		}
+	}
+	vmm.cache.mu.Lock()
+	defer vmm.cache.mu.Unlock()
+	if vmm.cache.generation != generation {
+		// Somebody has changed the VMs while we were reading them, our data may be old
+		vmm.log().Debug().Msg("The inventory has been invalidated while we were loading it, we discard it.")
+		return nil
+	}
+	vmm.cache.paths = make(map[string]string, len(vms))
+	vmm.cache.ids = make(map[string]string, len(vms))
+	vmm.cache.names = make(map[string][]string, len(vms))
+	vmm.cache.unnamed = 0
+	for pos, vm := range vms {
+		vmm.cache.paths[vm.IdVM] = vm.Path
+		vmm.cache.ids[vm.Path] = vm.IdVM
+		if _, failed := errs[pos]; failed {
+			vmm.cache.unnamed++
+			continue
+		}
+		vmm.cache.names[vm.Denomination] = append(vmm.cache.names[vm.Denomination], vm.IdVM)
+	}
+	vmm.cache.loaded = time.Now()
+	vmm.log().Info().Msgf("We have loaded %d VMs in the inventory.", len(vms))
+	return nil
+}
+
+// invalidate method clean the cache, the next lookup will load it again.
+func (vmm *VMManager) invalidate() {
+	if vmm.cache == nil {
+		return
+	}
+	vmm.cache.mu.Lock()
+	defer vmm.cache.mu.Unlock()
+	vmm.cache.generation++
+	vmm.cache.loaded = time.Time{}
+	vmm.log().Debug().Msg("We have invalidated the inventory.")
+}
+
+// findVM method look for the VM with the key, in the cache if we use it or in the API if we don't.
+// When the VM isn't in the cache we load it again, maybe somebody has created it in the GUI, and
+// if the cache still isn't fresh after that, because somebody has invalidated it while we were
+// loading it or the TTL is too short, we ask the API.
+func (vmm *VMManager) findVM(ctx context.Context, key string, value string) (*MyVm, error) {
+	if vmm.cache == nil || value == "" {
+		return vmm.lookupVM(ctx, key, value)
+	}
+	vm, fresh, err := vmm.cache.find(key, value)
+	if fresh && !errors.Is(err, httpclient.ErrNotFound) {
+		vmm.log().Debug().Msgf("We have found the VM with the %s %#v in the inventory.", key, value)
+		return vm, err
+	}
+	err = vmm.RefreshContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vm, fresh, err = vmm.cache.find(key, value)
+	if !fresh {
+		vmm.log().Debug().Msgf("The inventory isn't fresh after the refresh, we look for the VM with the %s %#v in the API.", key, value)
+		return vmm.lookupVM(ctx, key, value)
+	}
+	return vm, err
+}
+
+// lookupVM method look for the VM with the key in the API.
+func (vmm *VMManager) lookupVM(ctx context.Context, key string, value string) (*MyVm, error) {
+	switch key {
+	case keyID:
+		return GetVMContext(ctx, vmm.vmclient, value)
+	case keyName:
+		return GetVMbyNameContext(ctx, vmm.vmclient, value)
+	default:
+		return GetVMbyPathContext(ctx, vmm.vmclient, value)
+	}
+}
+
+// find method look for the VM with the key in the cache, fresh is false when the cache is empty or too old,
+// and also with the names when some VM doesn't have name in the cache, we can't trust them.
+func (inv *inventory) find(key string, value string) (vm *MyVm, fresh bool, err error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.loaded.IsZero() || time.Since(inv.loaded) > inv.ttl {
+		return nil, false, nil
+	}
+	var candidates []MyVm
+	switch key {
+	case keyID:
+		if path, ok := inv.paths[value]; ok {
+			candidates = append(candidates, MyVm{IdVM: value, Path: path})
+		}
+	case keyName:
+		if inv.unnamed > 0 {
+			return nil, false, nil
+		}
+		for _, id := range inv.names[value] {
+			candidates = append(candidates, MyVm{IdVM: id, Path: inv.paths[id]})
+		}
+	default:
+		if id, ok := inv.ids[value]; ok {
+			candidates = append(candidates, MyVm{IdVM: id, Path: value})
+		}
+	}
+	vm, err = uniqueVM(candidates, key, value)
+	if err != nil {
+		return nil, true, fmt.Errorf("get VM by %s %q: %w", key, value, err)
+	}
+	return vm, true, nil
+}
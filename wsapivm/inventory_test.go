@@ -0,0 +1,156 @@
+package wsapivm
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestInventoryCache(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM, wsapitest.VM{ID: "OTHER", DisplayName: "other"})
+	vmm := New(vmc, WithInventoryCache(time.Minute), WithFields(FieldPowerState))
+	for range 3 {
+		vm, err := vmm.LoadVMbyName("parent")
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		if vm.IdVM != "PARENT" || vm.Path != "/vmware/parent/parent.vmx" {
+			t.Errorf("We haven't found the VM in the inventory: %#v", vm)
+		}
+	}
+	if server.CountRequests("GET", "vms") != 1 || server.CountRequests("GET", "vms/OTHER/params/displayName") != 1 {
+		t.Errorf("We should have loaded the inventory only once: %#v", server.Requests())
+	}
+	vm, err := vmm.LoadVMbyPath("/vmware/other/other.vmx")
+	if err != nil || vm.IdVM != "OTHER" {
+		t.Fatalf("We haven't found the VM by path in the inventory: %#v %#v", vm, err)
+	}
+	// A VM that somebody has created in the GUI, the miss load the inventory again
+	server.AddVM(wsapitest.VM{ID: "NEW", DisplayName: "new"})
+	vm, err = vmm.LoadVMbyName("new")
+	if err != nil || vm.IdVM != "NEW" || server.CountRequests("GET", "vms") != 2 {
+		t.Fatalf("The miss should refresh the inventory: %#v %#v", vm, err)
+	}
+	_, err = vmm.LoadVM("MISSING")
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+}
+
+func TestInventoryInvalidation(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM, wsapitest.VM{ID: "OTHER", DisplayName: "other"})
+	vmm := New(vmc, WithInventoryCache(time.Minute), WithFields(FieldPowerState))
+	err := vmm.Refresh()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.DeleteVM(&MyVm{IdVM: "OTHER"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, err = vmm.LoadVMbyName("other")
+	if !errors.Is(err, httpclient.ErrNotFound) || server.CountRequests("GET", "vms") != 2 {
+		t.Errorf("DeleteVM should invalidate the inventory: %#v %#v", err, server.Requests())
+	}
+	server.AddVM(wsapitest.VM{ID: "TWIN", DisplayName: "parent", Path: "/vmware/twin/twin.vmx"})
+	err = vmm.Refresh()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	_, err = vmm.LoadVMbyName("parent")
+	var ambiguous *AmbiguousVMError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 || server.CountRequests("GET", "vms") != 3 {
+		t.Errorf("The inventory should detect the ambiguous names: %#v", err)
+	}
+}
+
+func TestInventoryTTL(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vmm := New(vmc, WithInventoryCache(10*time.Millisecond), WithFields(FieldPowerState))
+	_, err := vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_, err = vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if server.CountRequests("GET", "vms") != 2 {
+		t.Errorf("The inventory should expire after the TTL: %#v", server.Requests())
+	}
+}
+
+func TestInventoryNotFresh(t *testing.T) {
+	t.Run("tiny TTL", func(t *testing.T) {
+		vmc, _ := newTestClient(t, parentVM)
+		vmm := New(vmc, WithInventoryCache(time.Nanosecond), WithFields(FieldPowerState))
+		for _, load := range []func() (*MyVm, error){
+			func() (*MyVm, error) { return vmm.LoadVM("PARENT") },
+			func() (*MyVm, error) { return vmm.LoadVMbyName("parent") },
+			func() (*MyVm, error) { return vmm.LoadVMbyPath("/vmware/parent/parent.vmx") },
+		} {
+			vm, err := load()
+			if err != nil || vm == nil || vm.IdVM != "PARENT" {
+				t.Fatalf("We should find the VM in the API when the inventory expires: %#v %#v", vm, err)
+			}
+		}
+	})
+	t.Run("invalidated during the refresh", func(t *testing.T) {
+		server := wsapitest.NewServer(parentVM)
+		t.Cleanup(server.Close)
+		var vmm *VMManager
+		invalidator := func(next http.RoundTripper) http.RoundTripper {
+			return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if strings.HasSuffix(req.URL.Path, "/params/displayName") {
+					vmm.invalidate()
+				}
+				return next.RoundTrip(req)
+			})
+		}
+		vmc, err := httpclient.NewClient(server.URL, server.User, server.Password, false, "NONE", httpclient.WithMiddleware(invalidator))
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		vmm = New(vmc, WithInventoryCache(time.Minute), WithFields(FieldPowerState)).(*VMManager)
+		vm, err := vmm.LoadVMbyName("parent")
+		if err != nil || vm == nil || vm.IdVM != "PARENT" {
+			t.Fatalf("We should find the VM in the API when the refresh is discarded: %#v %#v", vm, err)
+		}
+		_, err = vmm.LoadVM("MISSING")
+		if !errors.Is(err, httpclient.ErrNotFound) {
+			t.Errorf("The error should be ErrNotFound: %#v", err)
+		}
+	})
+}
+
+func TestInventoryNameFailure(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM, wsapitest.VM{ID: "OTHER", DisplayName: "other"})
+	server.On("GET", "vms/OTHER/params/displayName").Fail(http.StatusInternalServerError, 0, "Internal error")
+	vmm := New(vmc, WithInventoryCache(time.Minute), WithFields(FieldPowerState))
+	err := vmm.Refresh()
+	if err != nil {
+		t.Fatalf("A VM without name shouldn't stop the refresh: %#v", err)
+	}
+	vm, err := vmm.LoadVM("PARENT")
+	if err != nil || vm.IdVM != "PARENT" {
+		t.Fatalf("We haven't found the VM by ID in the inventory: %#v %#v", vm, err)
+	}
+	vm, err = vmm.LoadVMbyPath("/vmware/other/other.vmx")
+	if err != nil || vm.IdVM != "OTHER" {
+		t.Fatalf("We haven't found the VM without name by path in the inventory: %#v %#v", vm, err)
+	}
+	if server.CountRequests("GET", "vms") != 1 {
+		t.Errorf("We should have used the inventory: %#v", server.Requests())
+	}
+	// We can't trust the names of the inventory, so we ask the API like without the cache
+	_, err = vmm.LoadVMbyName("parent")
+	if err == nil || server.CountRequests("GET", "vms") < 2 {
+		t.Errorf("We should look for the name in the API, that fails with the VM without name: %#v %#v", err, server.Requests())
+	}
+}
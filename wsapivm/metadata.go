@@ -0,0 +1,213 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapiutils"
+)
+
+// MetadataStrategy is the way that we use to change the denomination and the description
+// of a VM, not all the versions of vmrest change them with the same endpoint.
+type MetadataStrategy int
+
+// These are the strategies that we can use, with MetadataAuto we probe them in this order
+// and we keep the first one that works.
+const (
+	MetadataAuto MetadataStrategy = iota
+	MetadataParams
+	MetadataConfigParams
+	MetadataVMX
+)
+
+// String method return the name of the strategy, we use it in the errors and the logs.
+func (s MetadataStrategy) String() string {
+	switch s {
+	case MetadataAuto:
+		return "auto"
+	case MetadataParams:
+		return "params"
+	case MetadataConfigParams:
+		return "configparams"
+	case MetadataVMX:
+		return "vmx"
+	default:
+		return fmt.Sprintf("MetadataStrategy(%d)", int(s))
+	}
+}
+
+// ErrMetadataUnsupported none of the strategies has been able to change the denomination and the description.
+var ErrMetadataUnsupported = errors.New("we can't change the denomination and the description")
+
+// WithMetadataStrategy option choose the way that CreateVM and UpdateVM change the denomination
+// and the description of the VMs, by default MetadataAuto.
+// Inputs:
+// s: (MetadataStrategy) The strategy, like MetadataVMX if the API doesn't work with our version.
+func WithMetadataStrategy(s MetadataStrategy) Option {
+	return func(vmm *VMManager) {
+		vmm.SetMetadataStrategy(s)
+	}
+}
+
+// SetMetadataStrategy method change the way that we change the denomination and the description,
+// with MetadataAuto we forget the strategy that we have found and we probe them again.
+// Inputs:
+// s: (MetadataStrategy) The strategy.
+func (vmm *VMManager) SetMetadataStrategy(s MetadataStrategy) {
+	vmm.metadata.mu.Lock()
+	defer vmm.metadata.mu.Unlock()
+	vmm.metadata.strategy = s
+	vmm.metadata.found = MetadataAuto
+}
+
+// WithRequiredMetadata option make that Create and CreateVM fail, and delete the new VM,
+// when they can't change its denomination and description. By default we keep the VM
+// with the values of the parent and we write a warning in the log.
+func WithRequiredMetadata() Option {
+	return func(vmm *VMManager) {
+		vmm.SetRequiredMetadata(true)
+	}
+}
+
+// SetRequiredMetadata method say if Create and CreateVM fail when they can't change the
+// denomination and the description of the new VM.
+// Inputs:
+// required: (bool) True to fail, false to keep the VM with a warning.
+func (vmm *VMManager) SetRequiredMetadata(required bool) {
+	vmm.metadata.mu.Lock()
+	defer vmm.metadata.mu.Unlock()
+	vmm.metadata.required = required
+}
+
+// createMetadata method put the denomination and the description of the specification in
+// the clone, we don't touch it if it already has them. If no strategy works the VM is still
+// useful, so unless the metadata is required we keep it with the values that it has.
+func (vmm *VMManager) createMetadata(ctx context.Context, vm *MyVm, spec CreateVMSpec) error {
+	err := GetDenominationDescriptionContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return err
+	}
+	d := spec.Description
+	if d == "" {
+		d = vm.Description
+	}
+	if vm.Denomination == spec.DisplayName && vm.Description == d {
+		vmm.log().Debug().Msgf("The VM %#v already has the denomination and the description.", vm.IdVM)
+		return nil
+	}
+	err = vmm.setMetadata(ctx, vm, spec.DisplayName, d, false)
+	vmm.metadata.mu.Lock()
+	required := vmm.metadata.required
+	vmm.metadata.mu.Unlock()
+	if !errors.Is(err, ErrMetadataUnsupported) || required {
+		return err
+	}
+	vmm.log().Warn().Err(err).Msgf("We keep the VM %#v with the denomination and the description that it has.", vm.IdVM)
+	return GetDenominationDescriptionContext(ctx, vmm.vmclient, vm)
+}
+
+// metadataStrategies method return the strategies that we have to try, the one that
+// has worked the last time first. When the VM is running we can't use MetadataVMX.
+func (vmm *VMManager) metadataStrategies(running bool) []MetadataStrategy {
+	vmm.metadata.mu.Lock()
+	defer vmm.metadata.mu.Unlock()
+	strategies := []MetadataStrategy{MetadataParams, MetadataConfigParams, MetadataVMX}
+	if vmm.metadata.strategy != MetadataAuto {
+		strategies = []MetadataStrategy{vmm.metadata.strategy}
+	} else if vmm.metadata.found != MetadataAuto {
+		strategies = slices.DeleteFunc(strategies, func(s MetadataStrategy) bool { return s == vmm.metadata.found })
+		strategies = slices.Insert(strategies, 0, vmm.metadata.found)
+	}
+	if running {
+		strategies = slices.DeleteFunc(strategies, func(s MetadataStrategy) bool { return s == MetadataVMX })
+	}
+	return strategies
+}
+
+// setMetadata method change the denomination and the description of the VM with the first
+// strategy that works, if the VM is running we don't touch the .vmx file because vmrest
+// and Workstation overwrite it.
+func (vmm *VMManager) setMetadata(ctx context.Context, vm *MyVm, n string, d string, running bool) error {
+	var errs []error
+	for _, strategy := range vmm.metadataStrategies(running) {
+		err := vmm.writeMetadata(ctx, vm, strategy, n, d)
+		if err == nil {
+			vmm.metadata.mu.Lock()
+			vmm.metadata.found = strategy
+			vmm.metadata.mu.Unlock()
+			vm.Denomination = n
+			vm.Description = d
+			vmm.log().Debug().Msgf("We have changed the denomination and the description of the VM %#v with %s", vm.IdVM, strategy)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("set denomination and description of VM %q: %w", vm.IdVM, err)
+		}
+		vmm.log().Debug().Msgf("We can't change the denomination and the description with %s: %s", strategy, err)
+		errs = append(errs, fmt.Errorf("%s: %w", strategy, err))
+	}
+	if len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("%s: we can't change the file of a running VM", MetadataVMX))
+	}
+	return fmt.Errorf("set denomination and description of VM %q: %w: %w", vm.IdVM, ErrMetadataUnsupported, errors.Join(errs...))
+}
+
+// writeMetadata method change the denomination and the description with one strategy,
+// with the API we read the values again because some versions accept the change and
+// don't do anything.
+func (vmm *VMManager) writeMetadata(ctx context.Context, vm *MyVm, strategy MetadataStrategy, n string, d string) error {
+	var set func(ctx context.Context, p string, v string) error
+	switch strategy {
+	case MetadataParams:
+		set = func(ctx context.Context, p string, v string) error {
+			return SetVMParameterContext(ctx, vmm.vmclient, vm, p, v)
+		}
+	case MetadataConfigParams:
+		set = func(ctx context.Context, p string, v string) error {
+			return SetParameterContext(ctx, vmm.vmclient, vm, p, v)
+		}
+	case MetadataVMX:
+		return vmm.writeVMX(ctx, vm, n, d)
+	default:
+		return fmt.Errorf("the strategy %s isn't valid", strategy)
+	}
+	params := [][2]string{{"displayName", n}, {"annotation", d}}
+	for _, param := range params {
+		err := set(ctx, param[0], param[1])
+		if err != nil {
+			return err
+		}
+	}
+	for _, param := range params {
+		value, err := GetParameterContext(ctx, vmm.vmclient, vm, param[0])
+		if err != nil {
+			return err
+		}
+		if value != param[1] {
+			return fmt.Errorf("the API has accepted the %s but it is still %q", param[0], value)
+		}
+	}
+	return nil
+}
+
+// writeVMX method change the denomination and the description in the .vmx file, we
+// can do it only when the API server is in the same host that us.
+func (vmm *VMManager) writeVMX(ctx context.Context, vm *MyVm, n string, d string) error {
+	path := vm.Path
+	if path == "" {
+		current, err := GetVMContext(ctx, vmm.vmclient, vm.IdVM)
+		if err != nil {
+			return err
+		}
+		path = current.Path
+		vm.Path = path
+	}
+	_, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("the file of the VM isn't reachable: %w", err)
+	}
+	return wsapiutils.New().SetDenominationDescription(path, n, d)
+}
@@ -0,0 +1,154 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestSetMetadata(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vmm := New(vmc)
+	vm, err := vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.UpdateVM(vm, "renamed", "The renamed VM", 2, 2048, "")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.DisplayName != "renamed" || stored.Annotation != "The renamed VM" {
+		t.Errorf("The VM hasn't the new denomination and description: %#v", stored)
+	}
+	if vm.Denomination != "renamed" || vm.Description != "The renamed VM" {
+		t.Errorf("The VM that we have isn't updated: %#v", vm)
+	}
+	if server.CountRequests("PUT", "vms/PARENT/params") != 2 || server.CountRequests("PUT", "vms/PARENT/configparams") != 0 {
+		t.Errorf("We should use the params endpoint: %#v", server.Requests())
+	}
+}
+
+func TestSetMetadataProbe(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	params := server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	vmm := New(vmc)
+	vm, err := vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.UpdateVM(vm, "renamed", "", 2, 2048, "")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.DisplayName != "renamed" || stored.Annotation != "The parent VM" || server.CountRequests("PUT", "vms/PARENT/configparams") != 2 {
+		t.Errorf("We should have used the configparams endpoint: %#v", stored)
+	}
+	err = vmm.UpdateVM(vm, "", "Again", 2, 2048, "")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if params.Applied() != 1 || server.CountRequests("PUT", "vms/PARENT/configparams") != 4 {
+		t.Errorf("We should remember the strategy that works: %#v", server.Requests())
+	}
+	stored, _ = server.VM("PARENT")
+	if stored.DisplayName != "renamed" || stored.Annotation != "Again" {
+		t.Errorf("An empty denomination should keep the current one: %#v", stored)
+	}
+}
+
+func TestSetMetadataVMX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parent.vmx")
+	err := os.WriteFile(path, []byte("displayName = \"parent\"\nannotation = \"The parent VM\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	vm := parentVM
+	vm.Path = path
+	vmc, server := newTestClient(t, vm)
+	server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	server.On("PUT", "vms/*/configparams").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	myvm := &MyVm{IdVM: "PARENT"}
+	err = New(vmc).(*VMManager).setMetadata(context.Background(), myvm, "renamed", "From the file", false)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "renamed") || !strings.Contains(string(data), "From the file") {
+		t.Errorf("We haven't changed the .vmx file: %s %#v", data, err)
+	}
+	if myvm.Path != path {
+		t.Errorf("We should have loaded the Path of the VM: %#v", myvm)
+	}
+}
+
+func TestSetMetadataUnsupported(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	server.On("PUT", "vms/*/configparams").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	vmm := New(vmc)
+	vm, err := vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.UpdateVM(vm, "renamed", "", 2, 2048, "")
+	if !errors.Is(err, ErrMetadataUnsupported) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("The error should be ErrMetadataUnsupported with the error of all the strategies: %#v", err)
+	}
+	if !strings.Contains(err.Error(), "params") || !strings.Contains(err.Error(), "vmx") {
+		t.Errorf("The error should say the strategies that we have tried: %s", err)
+	}
+	vmm.SetMetadataStrategy(MetadataVMX)
+	err = vmm.UpdateVM(vm, "renamed", "", 2, 2048, "")
+	if !errors.Is(err, os.ErrNotExist) || server.CountRequests("PUT", "vms/PARENT/params") != 1 {
+		t.Errorf("We should only use the strategy that we have chosen: %#v", err)
+	}
+}
+
+func TestCreateMetadata(t *testing.T) {
+	unsupported := func(server *wsapitest.Server) {
+		server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+		server.On("PUT", "vms/*/configparams").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	}
+	t.Run("already there", func(t *testing.T) {
+		vmc, server := newTestClient(t, parentVM)
+		unsupported(server)
+		vm, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone"})
+		if err != nil {
+			t.Fatalf("%#v\n", err)
+		}
+		if server.CountRequests("PUT", "vms/"+vm.IdVM+"/params") != 0 || vm.Denomination != "clone" || vm.Description != "The parent VM" {
+			t.Errorf("We shouldn't change the denomination and the description: %#v", vm)
+		}
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		vmc, server := newTestClient(t, parentVM)
+		unsupported(server)
+		vm, err := New(vmc).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone", Description: "The clone"})
+		if err != nil {
+			t.Fatalf("We should keep the VM with a warning: %#v", err)
+		}
+		if _, ok := server.VM(vm.IdVM); !ok || vm.Description != "The parent VM" {
+			t.Errorf("The VM should have the values that it really has: %#v", vm)
+		}
+	})
+	t.Run("required", func(t *testing.T) {
+		vmc, server := newTestClient(t, parentVM)
+		unsupported(server)
+		_, err := New(vmc, WithRequiredMetadata()).Create(CreateVMSpec{ParentID: "PARENT", DisplayName: "clone", Description: "The clone"})
+		var rollback *RollbackError
+		if !errors.As(err, &rollback) || !errors.Is(err, ErrMetadataUnsupported) {
+			t.Fatalf("The error should be a RollbackError with ErrMetadataUnsupported: %#v", err)
+		}
+		if _, ok := server.VM(rollback.IdVM); ok {
+			t.Errorf("We should have deleted the clone %q", rollback.IdVM)
+		}
+	})
+}
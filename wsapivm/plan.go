@@ -0,0 +1,187 @@
+package wsapivm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Plan is the list of changes that an update will make in a VM, we can show it to
+// review it before we apply it with ApplyPlan.
+// IdVM: (string) The ID of the VM.
+// Base: (map[string]string) The values of the fields of the VM when we made the plan, we use them to know if the VM has changed.
+// Changes: ([]Change) The changes that we will make, empty if the VM already has what we want.
+// Spec: (UpdateVMSpec) The specification with only the fields of the changes.
+type Plan struct {
+	IdVM    string            `json:"id"`
+	Base    map[string]string `json:"base"`
+	Changes []Change          `json:"changes"`
+	Spec    UpdateVMSpec      `json:"spec"`
+}
+
+// Changed method return true if the plan has something to change.
+func (p *Plan) Changed() bool {
+	return len(p.Changes) > 0
+}
+
+// RequiresPowerOff method return true if some change of the plan needs the VM stopped.
+func (p *Plan) RequiresPowerOff() bool {
+	for _, change := range p.Changes {
+		if change.RequiresPowerOff {
+			return true
+		}
+	}
+	return false
+}
+
+// String method return the plan as text, one line for each change, like:
+//
+//	Plan for VM "ID": 2 changes, the VM has to be stopped
+//	  ~ Description: "old" => "new"
+//	  ~ CPUs: "2" => "4" (requires power off)
+func (p *Plan) String() string {
+	if len(p.Changes) == 0 {
+		return fmt.Sprintf("Plan for VM %q: no changes", p.IdVM)
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Plan for VM %q: %d changes", p.IdVM, len(p.Changes))
+	if p.RequiresPowerOff() {
+		text.WriteString(", the VM has to be stopped")
+	}
+	for _, change := range p.Changes {
+		fmt.Fprintf(&text, "\n  ~ %s: %q => %q", change.Field, change.Old, change.New)
+		var notes []string
+		if change.RequiresPowerOff {
+			notes = append(notes, "requires power off")
+		}
+		if change.RequiresNICRecreation {
+			notes = append(notes, "requires NIC recreation")
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&text, " (%s)", strings.Join(notes, ", "))
+		}
+	}
+	return text.String()
+}
+
+// JSON method return the plan as indented JSON.
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// PlanUpdate method compare the VM with the specification and return the changes that
+// Update would make, without change anything.
+// Input:
+// vm: (*MyVm) The VM that we want to update, we put in it the current values.
+// spec: (UpdateVMSpec) The state that we want.
+// Output:
+// (*Plan) The changes, we can apply them with ApplyPlan.
+// error: (error) An error with ErrInvalidSpec if the specification isn't valid or the error of the API.
+func (vmm *VMManager) PlanUpdate(vm *MyVm, spec UpdateVMSpec) (*Plan, error) {
+	return vmm.PlanUpdateContext(context.Background(), vm, spec)
+}
+
+// PlanUpdateContext is the same as PlanUpdate but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) PlanUpdateContext(ctx context.Context, vm *MyVm, spec UpdateVMSpec) (*Plan, error) {
+	plan, err := vmm.plan(ctx, vm, spec)
+	if err != nil {
+		return nil, fmt.Errorf("plan update of VM %q: %w", vm.IdVM, err)
+	}
+	vmm.log().Debug().Msgf("We have planned %d changes for the VM %#v", len(plan.Changes), vm.IdVM)
+	return plan, nil
+}
+
+// ApplyPlan method make exactly the changes of the plan, before we read the VM again
+// and if it isn't like when we made the plan we don't change anything. We never stop
+// the VM if the plan doesn't say it, so if the API can't change the denomination and
+// the description of the running VM we fail without change anything.
+// Input:
+// vm: (*MyVm) The VM of the plan.
+// plan: (*Plan) The plan that PlanUpdate give us.
+// Output:
+// (*UpdateReport) What we have changed and if we have restarted the VM.
+// error: (error) A *DriftError if the VM has changed since the plan, ErrMetadataUnsupported if we
+// would need to stop the VM to change the denomination and the description, or the error of the API.
+func (vmm *VMManager) ApplyPlan(vm *MyVm, plan *Plan) (*UpdateReport, error) {
+	return vmm.ApplyPlanContext(context.Background(), vm, plan)
+}
+
+// ApplyPlanContext is the same as ApplyPlan but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) ApplyPlanContext(ctx context.Context, vm *MyVm, plan *Plan) (*UpdateReport, error) {
+	if plan.IdVM != vm.IdVM {
+		return nil, fmt.Errorf("apply plan of VM %q: the plan is for the VM %q", vm.IdVM, plan.IdVM)
+	}
+	_, nics := plan.Base[ChangeNICs]
+	current, err := vmm.snapshot(ctx, vm, nics)
+	if err != nil {
+		return nil, fmt.Errorf("apply plan of VM %q: %w", vm.IdVM, err)
+	}
+	var drifts []Change
+	for _, field := range changeFields {
+		planned, ok := plan.Base[field]
+		if ok && current[field] != planned {
+			drifts = append(drifts, Change{Field: field, Old: planned, New: current[field]})
+		}
+	}
+	if len(drifts) > 0 {
+		return nil, fmt.Errorf("apply plan of VM %q: %w", vm.IdVM, &DriftError{IdVM: vm.IdVM, Drifts: drifts})
+	}
+	report, err := vmm.execute(ctx, vm, plan, false)
+	if err != nil {
+		return report, fmt.Errorf("apply plan of VM %q: %w", vm.IdVM, err)
+	}
+	return report, nil
+}
+
+// plan method read the VM and compare it with the specification.
+func (vmm *VMManager) plan(ctx context.Context, vm *MyVm, spec UpdateVMSpec) (*Plan, error) {
+	err := spec.Validate()
+	if err != nil {
+		return nil, err
+	}
+	base, err := vmm.snapshot(ctx, vm, spec.NICs != nil)
+	if err != nil {
+		return nil, err
+	}
+	changes := spec.diff(base)
+	return &Plan{IdVM: vm.IdVM, Base: base, Changes: changes, Spec: spec.only(changes)}, nil
+}
+
+// snapshot method load in the VM the values that an update can change and return them as
+// text, the NICs need one API call more so we only read them if we need them.
+func (vmm *VMManager) snapshot(ctx context.Context, vm *MyVm, nics bool) (map[string]string, error) {
+	err := GetBasicInfoContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return nil, err
+	}
+	err = GetDenominationDescriptionContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return nil, err
+	}
+	err = GetPowerStatusContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{
+		ChangeDisplayName: vm.Denomination,
+		ChangeDescription: vm.Description,
+		ChangeCPUs:        fmt.Sprint(vm.CPU.Processors),
+		ChangeMemory:      fmt.Sprint(vm.Memory),
+		ChangePowerState:  vm.PowerStatus,
+	}
+	if nics {
+		current, err := GetNICsContext(ctx, vmm.vmclient, vm)
+		if err != nil {
+			return nil, err
+		}
+		specs := make([]NICSpec, 0, len(current.NICS))
+		for _, nic := range current.NICS {
+			specs = append(specs, NICSpec{Type: nic.Type, Vmnet: nic.Vmnet})
+		}
+		values[ChangeNICs] = formatNICs(specs)
+	}
+	return values, nil
+}
@@ -0,0 +1,167 @@
+package wsapivm
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestPlanUpdate(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	description := "The parent VM"
+	plan, err := New(vmc).PlanUpdate(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{
+		Description: &description,
+		CPUs:        4,
+		NICs:        []NICSpec{{Type: NICBridged}, {Type: NICCustom, Vmnet: "vmnet2"}},
+	})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	want := []Change{
+		{Field: ChangeCPUs, Old: "2", New: "4", RequiresPowerOff: true},
+		{Field: ChangeNICs, Old: "nat", New: "bridged, custom:vmnet2", RequiresPowerOff: true, RequiresNICRecreation: true},
+	}
+	if len(plan.Changes) != len(want) {
+		t.Fatalf("We want the changes %#v, we have %#v", want, plan.Changes)
+	}
+	for i := range want {
+		if plan.Changes[i] != want[i] {
+			t.Errorf("We want the change %#v, we have %#v", want[i], plan.Changes[i])
+		}
+	}
+	if plan.Spec.Description != nil || plan.Spec.CPUs != 4 || len(plan.Spec.NICs) != 2 || !plan.RequiresPowerOff() {
+		t.Errorf("The plan should only have the fields that change: %#v", plan.Spec)
+	}
+	for _, request := range server.Requests() {
+		if request.Method != "GET" {
+			t.Errorf("We shouldn't change anything: %#v", request)
+		}
+	}
+}
+
+func TestPlanRender(t *testing.T) {
+	vmc, _ := newTestClient(t, runningVM())
+	vmm := New(vmc)
+	plan, err := vmm.PlanUpdate(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{DisplayName: "renamed", Memory: 4096})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	text := plan.String()
+	for _, line := range []string{
+		`Plan for VM "PARENT": 2 changes, the VM has to be stopped`,
+		`~ DisplayName: "parent" => "renamed"`,
+		`~ Memory: "2048" => "4096" (requires power off)`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("The text of the plan doesn't have %q:\n%s", line, text)
+		}
+	}
+	data, err := plan.JSON()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	var decoded Plan
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if decoded.IdVM != "PARENT" || len(decoded.Changes) != 2 || decoded.Changes[1] != plan.Changes[1] || decoded.Spec.Memory != 4096 {
+		t.Errorf("The JSON of the plan isn't the same plan: %s", data)
+	}
+	empty, err := vmm.PlanUpdate(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{CPUs: 2})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if empty.Changed() || empty.String() != `Plan for VM "PARENT": no changes` {
+		t.Errorf("The plan shouldn't have changes: %s", empty)
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	vmm := New(vmc)
+	vm := &MyVm{IdVM: "PARENT"}
+	plan, err := vmm.PlanUpdate(vm, UpdateVMSpec{DisplayName: "renamed", CPUs: 4, NICs: []NICSpec{{Type: NICBridged}}})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	report, err := vmm.ApplyPlan(vm, plan)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(report.Changes) != len(plan.Changes) || !report.Restarted {
+		t.Errorf("We should have made the changes of the plan: %#v", report)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.DisplayName != "renamed" || stored.Processors != 4 || stored.Memory != 2048 || stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("The VM hasn't been updated: %#v", stored)
+	}
+	if len(stored.NICs) != 1 || stored.NICs[0].Type != NICBridged {
+		t.Errorf("The VM hasn't the NICs of the plan: %#v", stored.NICs)
+	}
+}
+
+func TestApplyPlanDrift(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	vmm := New(vmc)
+	vm := &MyVm{IdVM: "PARENT"}
+	plan, err := vmm.PlanUpdate(vm, UpdateVMSpec{CPUs: 4})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	server.SetPowerState("PARENT", wsapitest.PoweredOff)
+	server.ResetRequests()
+	_, err = vmm.ApplyPlan(vm, plan)
+	var drift *DriftError
+	if !errors.Is(err, ErrDrift) || !errors.As(err, &drift) {
+		t.Fatalf("The error should be a DriftError: %#v", err)
+	}
+	if len(drift.Drifts) != 1 || drift.Drifts[0].Field != ChangePowerState || drift.Drifts[0].Old != "on" || drift.Drifts[0].New != "off" {
+		t.Errorf("The error should say that the power state has changed: %#v", drift.Drifts)
+	}
+	for _, request := range server.Requests() {
+		if request.Method != "GET" {
+			t.Errorf("We shouldn't change anything: %#v", request)
+		}
+	}
+	_, err = vmm.ApplyPlan(&MyVm{IdVM: "OTHER"}, plan)
+	if err == nil {
+		t.Errorf("We shouldn't apply the plan in another VM")
+	}
+}
+
+func TestApplyPlanMetadataNeedsPowerOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parent.vmx")
+	err := os.WriteFile(path, []byte("displayName = \"parent\"\nannotation = \"The parent VM\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	vm := runningVM()
+	vm.Path = path
+	vmc, server := newTestClient(t, vm)
+	server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	server.On("PUT", "vms/*/configparams").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	vmm := New(vmc)
+	myvm := &MyVm{IdVM: "PARENT"}
+	plan, err := vmm.PlanUpdate(myvm, UpdateVMSpec{DisplayName: "renamed"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if plan.RequiresPowerOff() {
+		t.Fatalf("The plan shouldn't stop the VM: %s", plan)
+	}
+	_, err = vmm.ApplyPlan(myvm, plan)
+	if !errors.Is(err, ErrMetadataUnsupported) {
+		t.Fatalf("We should fail instead of stop the VM: %#v", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.PowerState != wsapitest.PoweredOn || server.CountRequests("PUT", "vms/PARENT/power") != 0 {
+		t.Errorf("We shouldn't have stopped the VM: %#v", server.Requests())
+	}
+}
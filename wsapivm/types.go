@@ -1,21 +1,93 @@
 package wsapivm
 
-import "github.com/elsudano/vmware-workstation-api-client/httpclient"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/rs/zerolog"
+)
 
 // Interface with all the methods that we can use to talk with the API of VmWare Workstation Pro
 type VMService interface {
 	GetAllVMs() ([]MyVm, error)
 	LoadVM(i string) (*MyVm, error)
 	LoadVMbyName(n string) (*MyVm, error)
+	LoadVMbyPath(p string) (*MyVm, error)
 	CreateVM(pid string, n string, d string, p int32, m int32, s string) (*MyVm, error)
 	UpdateVM(vm *MyVm, n string, d string, p int32, m int32, s string) error
 	RegisterVM(vm *MyVm) error
 	DeleteVM(vm *MyVm) error
+	GetAllVMsContext(ctx context.Context) ([]MyVm, error)
+	LoadVMContext(ctx context.Context, i string) (*MyVm, error)
+	LoadVMbyNameContext(ctx context.Context, n string) (*MyVm, error)
+	LoadVMbyPathContext(ctx context.Context, p string) (*MyVm, error)
+	CreateVMContext(ctx context.Context, pid string, n string, d string, p int32, m int32, s string) (*MyVm, error)
+	Create(spec CreateVMSpec) (*MyVm, error)
+	CreateContext(ctx context.Context, spec CreateVMSpec) (*MyVm, error)
+	UpdateVMContext(ctx context.Context, vm *MyVm, n string, d string, p int32, m int32, s string) error
+	Update(vm *MyVm, spec UpdateVMSpec) (*UpdateReport, error)
+	UpdateContext(ctx context.Context, vm *MyVm, spec UpdateVMSpec) (*UpdateReport, error)
+	PlanUpdate(vm *MyVm, spec UpdateVMSpec) (*Plan, error)
+	PlanUpdateContext(ctx context.Context, vm *MyVm, spec UpdateVMSpec) (*Plan, error)
+	ApplyPlan(vm *MyVm, plan *Plan) (*UpdateReport, error)
+	ApplyPlanContext(ctx context.Context, vm *MyVm, plan *Plan) (*UpdateReport, error)
+	RegisterVMContext(ctx context.Context, vm *MyVm) error
+	DeleteVMContext(ctx context.Context, vm *MyVm) error
+	Power(vm *MyVm, op PowerOperation) error
+	Suspend(vm *MyVm) error
+	Pause(vm *MyVm) error
+	Unpause(vm *MyVm) error
+	Shutdown(vm *MyVm) error
+	Reset(vm *MyVm) error
+	PowerContext(ctx context.Context, vm *MyVm, op PowerOperation) error
+	SuspendContext(ctx context.Context, vm *MyVm) error
+	PauseContext(ctx context.Context, vm *MyVm) error
+	UnpauseContext(ctx context.Context, vm *MyVm) error
+	ShutdownContext(ctx context.Context, vm *MyVm) error
+	ResetContext(ctx context.Context, vm *MyVm) error
+	StopVM(vm *MyVm) error
+	StopVMContext(ctx context.Context, vm *MyVm) error
+	SetStopPolicy(p StopPolicy)
+	WaitForPowerState(ctx context.Context, vm *MyVm, state PowerState, opts ...WaitOptions) error
+	WaitForIP(ctx context.Context, vm *MyVm, opts ...WaitOptions) (string, error)
+	SetGuestNetwork(enabled bool)
+	SetFields(f VMField)
+	SetConcurrency(n int)
+	SetInventoryCache(ttl time.Duration)
+	SetRollback(enabled bool)
+	SetMetadataStrategy(s MetadataStrategy)
+	SetRequiredMetadata(required bool)
+	Refresh() error
+	RefreshContext(ctx context.Context) error
+	SetLogger(l *zerolog.Logger)
 }
 
+// Option is a function that we can use to change the settings of the VMManager when we create it.
+type Option func(vmm *VMManager)
+
 // That's the Manager to make the calls
 type VMManager struct {
-	vmclient *httpclient.HTTPClient
+	vmclient    *httpclient.HTTPClient
+	logger      *zerolog.Logger
+	stopPolicy  StopPolicy
+	fields      VMField
+	concurrency int
+	cache       *inventory
+	keepFailed  bool
+	metadata    metadata
+}
+
+// metadata is the strategy that the manager use to change the denomination and the description.
+// strategy: (MetadataStrategy) The strategy that we have chosen, MetadataAuto to probe them.
+// found: (MetadataStrategy) The strategy that has worked the last time with MetadataAuto.
+// required: (bool) True if Create fails when it can't change them, by default it's a warning.
+type metadata struct {
+	mu       sync.Mutex
+	strategy MetadataStrategy
+	found    MetadataStrategy
+	required bool
 }
 
 // That's the abstract object that how we see our VM's
@@ -30,15 +102,64 @@ type MyVm struct {
 	CPU          struct {
 		Processors int32 `json:"processors"`
 	}
-	NICS []struct {
-		Mac string   `json:"mac"`
-		Ip  []string `json:"ip"`
-	}
-	DNS struct {
-		Hostname   string   `json:"hostname"`
-		Domainname string   `json:"domainname"`
-		Servers    []string `json:"server"`
-	}
+	IP     string `json:"ip"`
+	NICS   []GuestNIC
+	DNS    GuestDNS
+	Routes []GuestRoute
+	WINS   GuestWINS
+	DHCPv4 GuestDHCP
+	DHCPv6 GuestDHCP
+}
+
+// This's the complete information of Network on a VM, the guest OS give it to us
+// with the VMware Tools when the VM is on.
+type InfoNetwork struct {
+	Nics   []GuestNIC   `json:"nics"`
+	Routes []GuestRoute `json:"routes"`
+	Dns    GuestDNS     `json:"dns"`
+	Wins   GuestWINS    `json:"wins"`
+	Dhcpv4 GuestDHCP    `json:"dhcpv4"`
+	Dhcpv6 GuestDHCP    `json:"dhcpv6"`
+}
+
+// GuestNIC is a network adapter as the guest OS see it, with its addresses.
+type GuestNIC struct {
+	Mac   string    `json:"mac"`
+	Ip    []string  `json:"ip"`
+	Dns   GuestDNS  `json:"dns"`
+	Wins  GuestWINS `json:"wins"`
+	Dhcp4 GuestDHCP `json:"dhcp4"`
+	Dhcp6 GuestDHCP `json:"dhcp6"`
+}
+
+// GuestDNS are the DNS settings of the guest OS.
+type GuestDNS struct {
+	Hostname   string   `json:"hostname"`
+	Domainname string   `json:"domainname"`
+	Servers    []string `json:"server"`
+	Search     []string `json:"search"`
+}
+
+// GuestWINS are the WINS servers of the guest OS.
+type GuestWINS struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+}
+
+// GuestDHCP says if the guest OS use DHCP and how.
+type GuestDHCP struct {
+	Enabled  bool   `json:"enabled"`
+	Settings string `json:"settings"`
+}
+
+// GuestRoute is a route of the routing table of the guest OS.
+type GuestRoute struct {
+	Dest      string `json:"dest"`
+	Prefix    int32  `json:"prefix"`
+	Nexthop   string `json:"nexthop"`
+	Interface int32  `json:"interface"`
+	Type      int32  `json:"type"`
+	Metric    int32  `json:"metric"`
 }
 
 // This struct is for create a VM, just for create because the API needs
@@ -65,6 +186,97 @@ type ParamPayload struct {
 	Value string `json:"value"`
 }
 
+// This struct is for get the IP that the guest OS report
+type IPPayload struct {
+	IP string `json:"ip"`
+}
+
+// This struct is for get the NICs of the VM, the same that the API give us in vms/{id}/nic
+type NICsPayload struct {
+	Num  int          `json:"num"`
+	NICS []NICPayload `json:"nics"`
+}
+
+// This struct is for get the information of one NIC of the VM
+type NICPayload struct {
+	Index int32  `json:"index"`
+	Type  string `json:"type"`
+	Vmnet string `json:"vmnet"`
+	Mac   string `json:"macAddress"`
+}
+
+// PowerState is the normalized Power State of a VM, the same values that we keep in MyVm.PowerStatus
+type PowerState string
+
+// These are the Power States that a VM can have
+const (
+	PowerStateOn        PowerState = "on"
+	PowerStateOff       PowerState = "off"
+	PowerStateSuspended PowerState = "suspended"
+	PowerStatePaused    PowerState = "paused"
+	PowerStateInvalid   PowerState = "Invalid Power State"
+)
+
+// PowerOperation is the operation that we send at the API to change the Power State of a VM
+type PowerOperation string
+
+// These are the operations that the API of VmWare Workstation Pro accept
+const (
+	PowerOperationOn       PowerOperation = "on"
+	PowerOperationOff      PowerOperation = "off"
+	PowerOperationShutdown PowerOperation = "shutdown"
+	PowerOperationSuspend  PowerOperation = "suspend"
+	PowerOperationPause    PowerOperation = "pause"
+	PowerOperationUnpause  PowerOperation = "unpause"
+	PowerOperationReset    PowerOperation = "reset"
+)
+
+// VMField is a group of values of MyVm that we load with their own API calls, we can
+// combine them like FieldBasicInfo | FieldPowerState.
+type VMField uint
+
+// These are the groups of values that LoadVM and GetAllVMs can load
+const (
+	// FieldBasicInfo are the Processors and the Memory, one API call.
+	FieldBasicInfo VMField = 1 << iota
+	// FieldDenominationDescription are the Denomination and the Description, two API calls.
+	FieldDenominationDescription
+	// FieldPowerState is the PowerStatus, one API call.
+	FieldPowerState
+	// FieldGuestNetwork is the network of the guest OS, two API calls more when the VM is on,
+	// it needs the Power State so we load it too.
+	FieldGuestNetwork
+	// DefaultFields are the values that we load if we don't say anything.
+	DefaultFields = FieldBasicInfo | FieldDenominationDescription | FieldPowerState
+)
+
+// StopMode says what we do to stop a VM.
+type StopMode int
+
+// These are the ways to stop a VM
+const (
+	// StopGracefulThenHard ask the guest OS to shut down and, if it's still running
+	// after the Timeout, cut the power. It's the default.
+	StopGracefulThenHard StopMode = iota
+	// StopHard cut the power of the VM, like we pull out the plug.
+	StopHard
+	// StopGraceful ask the guest OS to shut down and never cut the power, if the
+	// guest is still running after the Timeout we have an error.
+	StopGraceful
+)
+
+// StopPolicy are the settings that we use to stop a VM before we update it or delete it.
+// Mode: (StopMode) The way to stop the VM, by default StopGracefulThenHard.
+// Timeout: (time.Duration) Time that we wait for the guest OS, 0 means DefaultStopTimeout.
+// PollInterval: (time.Duration) Time between two reads of the Power State, 0 means DefaultStopPollInterval.
+// DiscardState: (bool) True to cut the power of the suspended or paused VMs, losing the state of their memory.
+type StopPolicy struct {
+	Mode         StopMode
+	Timeout      time.Duration
+	PollInterval time.Duration
+	DiscardState bool
+}
+
 // This struct is for get and put information about of any Power State of the VM
 type PowerStatePayload struct {
 	Value string `json:"power_state"`
@@ -0,0 +1,368 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// These are the fields that an update can change, we use them in the Field of Change.
+const (
+	ChangeDisplayName = "DisplayName"
+	ChangeDescription = "Description"
+	ChangeCPUs        = "CPUs"
+	ChangeMemory      = "Memory"
+	ChangeNICs        = "NICs"
+	ChangePowerState  = "PowerState"
+)
+
+// changeFields are the fields that an update can change, in the order that we show them.
+var changeFields = []string{ChangeDisplayName, ChangeDescription, ChangeCPUs, ChangeMemory, ChangeNICs, ChangePowerState}
+
+// UpdateVMSpec is the state that we want for a VM, the empty fields mean that we keep the
+// current value.
+// DisplayName: (string) The denomination of the VM.
+// Description: (*string) The description of the VM, nil means that we keep it, a pointer at "" remove it.
+// CPUs: (int32) The number of processors.
+// Memory: (int32) The memory in MB, multiple of 4.
+// NICs: ([]NICSpec) The NICs of the VM, nil means that we keep them, if they change we create all of them again.
+// PowerState: (PowerState) PowerStateOn or PowerStateOff, empty means the Power State that the VM had.
+type UpdateVMSpec struct {
+	DisplayName string     `json:"display_name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	CPUs        int32      `json:"cpus,omitempty"`
+	Memory      int32      `json:"memory,omitempty"`
+	NICs        []NICSpec  `json:"nics,omitempty"`
+	PowerState  PowerState `json:"power_state,omitempty"`
+}
+
+// Change is one field that an update changes.
+// Field: (string) The field, like ChangeMemory.
+// Old: (string) The value that the VM has.
+// New: (string) The value that we want.
+// RequiresPowerOff: (bool) True if vmrest doesn't allow the change while the VM is running.
+// RequiresNICRecreation: (bool) True if we have to delete the NICs and create them again, they will have new MAC addresses.
+type Change struct {
+	Field                 string `json:"field"`
+	Old                   string `json:"old"`
+	New                   string `json:"new"`
+	RequiresPowerOff      bool   `json:"requires_power_off"`
+	RequiresNICRecreation bool   `json:"requires_nic_recreation"`
+}
+
+// UpdateReport says what an update has done.
+// Changes: ([]Change) The fields that we have changed, empty if the VM already had what we want.
+// Restarted: (bool) True if we have stopped the VM to make the changes and we have started it again.
+type UpdateReport struct {
+	Changes   []Change `json:"changes"`
+	Restarted bool     `json:"restarted"`
+}
+
+// Changed method return true if the update has changed something.
+func (r *UpdateReport) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// Validate method check the specification without any API call.
+// Output:
+// error: (error) nil if the specification is valid, if not all the *SpecError that we have found joined.
+func (spec UpdateVMSpec) Validate() error {
+	var errs []error
+	if spec.CPUs < 0 {
+		errs = append(errs, &SpecError{Field: "CPUs", Reason: fmt.Sprintf("can't be negative: %d", spec.CPUs)})
+	}
+	if spec.Memory < 0 || spec.Memory%4 != 0 {
+		errs = append(errs, &SpecError{Field: "Memory", Reason: fmt.Sprintf("has to be 0 or a positive multiple of 4 MB: %d", spec.Memory)})
+	}
+	if spec.NICs != nil && len(spec.NICs) == 0 {
+		errs = append(errs, &SpecError{Field: "NICs", Reason: "can't be empty, use nil to keep the NICs"})
+	}
+	errs = append(errs, validateNICs(spec.NICs)...)
+	switch spec.PowerState {
+	case "", PowerStateOn, PowerStateOff:
+	default:
+		errs = append(errs, &SpecError{Field: "PowerState", Reason: fmt.Sprintf("has to be %q or %q: %q", PowerStateOn, PowerStateOff, spec.PowerState)})
+	}
+	return errors.Join(errs...)
+}
+
+// values method return the value of the fields that the specification want to change,
+// in the same format that snapshot.
+func (spec UpdateVMSpec) values() map[string]string {
+	values := make(map[string]string)
+	if spec.DisplayName != "" {
+		values[ChangeDisplayName] = spec.DisplayName
+	}
+	if spec.Description != nil {
+		values[ChangeDescription] = *spec.Description
+	}
+	if spec.CPUs > 0 {
+		values[ChangeCPUs] = fmt.Sprint(spec.CPUs)
+	}
+	if spec.Memory > 0 {
+		values[ChangeMemory] = fmt.Sprint(spec.Memory)
+	}
+	if spec.NICs != nil {
+		values[ChangeNICs] = formatNICs(spec.NICs)
+	}
+	if spec.PowerState != "" {
+		values[ChangePowerState] = string(spec.PowerState)
+	}
+	return values
+}
+
+// diff method return the changes that we need to make in a VM with the values of base to have the specification.
+func (spec UpdateVMSpec) diff(base map[string]string) []Change {
+	var changes []Change
+	values := spec.values()
+	for _, field := range changeFields {
+		value, ok := values[field]
+		if !ok || base[field] == value {
+			continue
+		}
+		changes = append(changes, Change{
+			Field:                 field,
+			Old:                   base[field],
+			New:                   value,
+			RequiresPowerOff:      field == ChangeCPUs || field == ChangeMemory || field == ChangeNICs,
+			RequiresNICRecreation: field == ChangeNICs,
+		})
+	}
+	return changes
+}
+
+// only method return the specification with only the fields of the changes.
+func (spec UpdateVMSpec) only(changes []Change) UpdateVMSpec {
+	var filtered UpdateVMSpec
+	for _, change := range changes {
+		switch change.Field {
+		case ChangeDisplayName:
+			filtered.DisplayName = spec.DisplayName
+		case ChangeDescription:
+			filtered.Description = spec.Description
+		case ChangeCPUs:
+			filtered.CPUs = spec.CPUs
+		case ChangeMemory:
+			filtered.Memory = spec.Memory
+		case ChangeNICs:
+			filtered.NICs = spec.NICs
+		case ChangePowerState:
+			filtered.PowerState = spec.PowerState
+		}
+	}
+	return filtered
+}
+
+// formatNICs function return the NICs as text, like "nat, custom:vmnet2", the virtual network
+// is only important in the custom NICs because the rest of them always use the same.
+func formatNICs(nics []NICSpec) string {
+	text := make([]string, 0, len(nics))
+	for _, nic := range nics {
+		if nic.Type == NICCustom {
+			text = append(text, nic.Type+":"+nic.Vmnet)
+		} else {
+			text = append(text, nic.Type)
+		}
+	}
+	return strings.Join(text, ", ")
+}
+
+// Update method change the VM until it has the specification, we compare it with the
+// current state of the VM and we only make the changes that we need. The denomination
+// and the description change without stop the VM, and we only stop it for the changes
+// that vmrest doesn't allow while it's running, like the processors and the memory. If
+// a change fails after we have stopped the VM we start it again before return the error.
+// It's the same as PlanUpdate and ApplyPlan, but without look for changes between them.
+// Input:
+// vm: (*MyVm) The VM that we want to update, we put in it the current values.
+// spec: (UpdateVMSpec) The state that we want.
+// Output:
+// (*UpdateReport) What we have changed and if we have restarted the VM.
+// error: (error) An error with ErrInvalidSpec if the specification isn't valid or the error of the API.
+func (vmm *VMManager) Update(vm *MyVm, spec UpdateVMSpec) (*UpdateReport, error) {
+	return vmm.UpdateContext(context.Background(), vm, spec)
+}
+
+// UpdateContext is the same as Update but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) UpdateContext(ctx context.Context, vm *MyVm, spec UpdateVMSpec) (*UpdateReport, error) {
+	plan, err := vmm.plan(ctx, vm, spec)
+	if err != nil {
+		return nil, fmt.Errorf("update VM %q: %w", vm.IdVM, err)
+	}
+	report, err := vmm.execute(ctx, vm, plan, true)
+	if err != nil {
+		return report, fmt.Errorf("update VM %q: %w", vm.IdVM, err)
+	}
+	return report, nil
+}
+
+// execute method make the changes of the plan in the VM, the VM has to have the values
+// that we have read in the last snapshot. With escalate we can stop the VM when we can't
+// change the denomination and the description while it's running, although the plan
+// doesn't say it.
+func (vmm *VMManager) execute(ctx context.Context, vm *MyVm, plan *Plan, escalate bool) (*UpdateReport, error) {
+	report := &UpdateReport{Changes: slices.Clone(plan.Changes)}
+	if !report.Changed() {
+		vmm.log().Info().Msg("The VM already has what we want, we don't need to update it.")
+		return report, nil
+	}
+	defer vmm.invalidate()
+	vmm.log().Debug().Msgf("State of VM before to update: %#v", vm)
+	err := vmm.apply(ctx, vm, plan.Spec, report, escalate)
+	if err != nil {
+		return report, err
+	}
+	vmm.log().Debug().Msgf("State of VM after to update: %#v", vm)
+	vmm.log().Info().Msgf("We have updated the VM with %d changes.", len(report.Changes))
+	return report, nil
+}
+
+// apply method make the changes of the report in the VM, first the changes that
+// we can make while the VM is running and then the rest, with the VM stopped. If
+// something fails after we have stopped the VM we try to start it again.
+func (vmm *VMManager) apply(ctx context.Context, vm *MyVm, spec UpdateVMSpec, report *UpdateReport, escalate bool) (err error) {
+	original := PowerState(vm.PowerStatus)
+	target := original
+	if spec.PowerState != "" {
+		target = spec.PowerState
+	}
+	running := original != PowerStateOff
+	cold := false
+	for _, change := range report.Changes {
+		cold = cold || change.RequiresPowerOff
+	}
+	metadata := report.has(ChangeDisplayName) || report.has(ChangeDescription)
+	n, d := vm.Denomination, vm.Description
+	if spec.DisplayName != "" {
+		n = spec.DisplayName
+	}
+	if spec.Description != nil {
+		d = *spec.Description
+	}
+	if metadata && running && !cold {
+		err := vmm.setMetadata(ctx, vm, n, d, true)
+		switch {
+		case errors.Is(err, ErrMetadataUnsupported) && !escalate:
+			return fmt.Errorf("%w, the plan doesn't stop the VM: plan the update with the VM stopped or use Update", err)
+		case errors.Is(err, ErrMetadataUnsupported):
+			vmm.log().Debug().Msgf("We need to stop the VM to change the denomination and the description: %s", err)
+			report.markPowerOff(ChangeDisplayName, ChangeDescription)
+			cold = true
+		case err != nil:
+			return err
+		default:
+			metadata = false
+		}
+	}
+	stopped := false
+	if cold && running {
+		err = vmm.StopVMContext(ctx, vm)
+		if err != nil {
+			return err
+		}
+		stopped = true
+		defer func() {
+			if err != nil {
+				restoreErr := vmm.restore(ctx, vm, original)
+				report.Restarted = restoreErr == nil
+				err = errors.Join(err, restoreErr)
+			}
+		}()
+	}
+	if report.has(ChangeCPUs) || report.has(ChangeMemory) {
+		p, m := spec.CPUs, spec.Memory
+		if p == 0 {
+			p = vm.CPU.Processors
+		}
+		if m == 0 {
+			m = vm.Memory
+		}
+		err := SetBasicInfoContext(ctx, vmm.vmclient, vm, p, m)
+		if err != nil {
+			return err
+		}
+		vmm.log().Debug().Msgf("We have put %#v processors and %#v memory in %#v VM", p, m, vm.Denomination)
+	}
+	if report.has(ChangeNICs) {
+		err := SetNICsContext(ctx, vmm.vmclient, vm, spec.NICs)
+		if err != nil {
+			return err
+		}
+	}
+	if metadata {
+		err := vmm.setMetadata(ctx, vm, n, d, running && !stopped)
+		if err != nil {
+			return err
+		}
+	}
+	err = vmm.powerTo(ctx, vm, target)
+	if err != nil {
+		return err
+	}
+	report.Restarted = stopped && target != PowerStateOff
+	return nil
+}
+
+// powerTo method take the VM to the Power State, we stop it with the StopPolicy.
+func (vmm *VMManager) powerTo(ctx context.Context, vm *MyVm, state PowerState) error {
+	switch current := PowerState(vm.PowerStatus); {
+	case current == state:
+		return nil
+	case state == PowerStateOff:
+		return vmm.StopVMContext(ctx, vm)
+	case current == PowerStatePaused && state == PowerStateOn:
+		return PowerOperateContext(ctx, vmm.vmclient, vm, PowerOperationUnpause)
+	}
+	err := PowerOperateContext(ctx, vmm.vmclient, vm, PowerOperationOn)
+	if err != nil {
+		return err
+	}
+	switch state {
+	case PowerStateSuspended:
+		return PowerOperateContext(ctx, vmm.vmclient, vm, PowerOperationSuspend)
+	case PowerStatePaused:
+		return PowerOperateContext(ctx, vmm.vmclient, vm, PowerOperationPause)
+	}
+	return nil
+}
+
+// restore method take the VM that we have stopped to the Power State that it had before the
+// update that has failed, with its own context because the one of the update can be done.
+func (vmm *VMManager) restore(ctx context.Context, vm *MyVm, state PowerState) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultRollbackTimeout)
+	defer cancel()
+	err := GetPowerStatusContext(ctx, vmm.vmclient, vm)
+	if err == nil {
+		err = vmm.powerTo(ctx, vm, state)
+	}
+	if err != nil {
+		vmm.log().Error().Err(err).Msgf("We couldn't take the VM %#v to %s again after the update failed.", vm.IdVM, state)
+		return fmt.Errorf("restore the power state %s: %w", state, err)
+	}
+	vmm.log().Info().Msgf("We have taken the VM %#v to %s again after the update failed.", vm.IdVM, state)
+	return nil
+}
+
+// has method return true if the report has a change of the field.
+func (r *UpdateReport) has(field string) bool {
+	for _, change := range r.Changes {
+		if change.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// markPowerOff method say that the changes of these fields need the VM stopped.
+func (r *UpdateReport) markPowerOff(fields ...string) {
+	for i := range r.Changes {
+		for _, field := range fields {
+			if r.Changes[i].Field == field {
+				r.Changes[i].RequiresPowerOff = true
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package wsapivm
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+// runningVM is the parent VM but powered on.
+func runningVM() wsapitest.VM {
+	vm := parentVM
+	vm.PowerState = wsapitest.PoweredOn
+	return vm
+}
+
+func TestUpdateNoop(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	description := "The parent VM"
+	report, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{DisplayName: "parent", Description: &description, CPUs: 2, Memory: 2048, PowerState: PowerStateOn})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if report.Changed() || report.Restarted {
+		t.Errorf("The VM already has what we want: %#v", report)
+	}
+	for _, request := range server.Requests() {
+		if request.Method != "GET" {
+			t.Errorf("We shouldn't change anything: %#v", request)
+		}
+	}
+}
+
+func TestUpdateHot(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	description := "The new description"
+	vm := &MyVm{IdVM: "PARENT"}
+	report, err := New(vmc).Update(vm, UpdateVMSpec{Description: &description, CPUs: 2})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	want := []Change{{Field: ChangeDescription, Old: "The parent VM", New: "The new description"}}
+	if len(report.Changes) != 1 || report.Changes[0] != want[0] || report.Restarted {
+		t.Errorf("We only want to change the description without restart: %#v", report)
+	}
+	if server.CountRequests("PUT", "vms/PARENT/power") != 0 || server.CountRequests("PUT", "vms/PARENT") != 0 {
+		t.Errorf("We shouldn't stop the VM: %#v", server.Requests())
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.Annotation != description || stored.PowerState != wsapitest.PoweredOn || vm.Description != description {
+		t.Errorf("The VM hasn't the new description: %#v", stored)
+	}
+}
+
+func TestUpdateCold(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     PowerState
+		restarted bool
+		stored    string
+	}{
+		{"keep the power state", "", true, wsapitest.PoweredOn},
+		{"power off", PowerStateOff, false, wsapitest.PoweredOff},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vmc, server := newTestClient(t, runningVM())
+			report, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{DisplayName: "renamed", CPUs: 4, PowerState: test.state})
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			if !report.has(ChangeCPUs) || !report.has(ChangeDisplayName) || report.has(ChangeMemory) || report.Restarted != test.restarted {
+				t.Errorf("The report doesn't say what we have done: %#v", report)
+			}
+			for _, change := range report.Changes {
+				if change.RequiresPowerOff != (change.Field == ChangeCPUs) {
+					t.Errorf("Only the processors need the VM stopped: %#v", change)
+				}
+			}
+			stored, _ := server.VM("PARENT")
+			if stored.Processors != 4 || stored.Memory != 2048 || stored.DisplayName != "renamed" || stored.PowerState != test.stored {
+				t.Errorf("The VM hasn't been updated: %#v", stored)
+			}
+		})
+	}
+}
+
+func TestUpdateColdFailure(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	server.On("PUT", "vms/*").Fail(http.StatusInternalServerError, 0, "Internal error")
+	report, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{CPUs: 4})
+	if err == nil {
+		t.Fatalf("The update should fail")
+	}
+	if server.CountRequests("PUT", "vms/PARENT/power") != 2 || !report.Restarted {
+		t.Errorf("We should have stopped the VM and started it again: %#v %#v", report, server.Requests())
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.PowerState != wsapitest.PoweredOn || stored.Processors != 2 {
+		t.Errorf("A failed update shouldn't leave the VM stopped: %#v", stored)
+	}
+}
+
+func TestUpdateMetadataNeedsPowerOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parent.vmx")
+	err := os.WriteFile(path, []byte("displayName = \"parent\"\nannotation = \"The parent VM\"\n"), 0644)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	vm := runningVM()
+	vm.Path = path
+	vmc, server := newTestClient(t, vm)
+	server.On("PUT", "vms/*/params").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	server.On("PUT", "vms/*/configparams").Fail(http.StatusBadRequest, wsapitest.CodeInvalidRequest, "Invalid parameter")
+	report, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{DisplayName: "renamed"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(report.Changes) != 1 || !report.Changes[0].RequiresPowerOff || !report.Restarted {
+		t.Errorf("We should have restarted the VM to change the .vmx file: %#v", report)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("The VM should be on again: %#v", stored)
+	}
+}
+
+func TestUpdateInvalidSpec(t *testing.T) {
+	vmc, server := newTestClient(t, runningVM())
+	_, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{Memory: 1023, PowerState: PowerStateSuspended})
+	if !errors.Is(err, ErrInvalidSpec) || len(server.Requests()) != 0 {
+		t.Errorf("We shouldn't call the API with an invalid specification: %#v", err)
+	}
+}
+
+func TestUpdateSuspended(t *testing.T) {
+	vm := parentVM
+	vm.PowerState = wsapitest.Suspended
+	vmc, server := newTestClient(t, vm)
+	report, err := New(vmc).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{CPUs: 4})
+	if !errors.Is(err, ErrInvalidState) || report.Restarted {
+		t.Fatalf("We shouldn't lose the state of a suspended VM: %#v", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.PowerState != wsapitest.Suspended || stored.Processors != 2 {
+		t.Errorf("We shouldn't have changed the VM: %#v", stored)
+	}
+	_, err = New(vmc, WithStopPolicy(StopPolicy{DiscardState: true})).Update(&MyVm{IdVM: "PARENT"}, UpdateVMSpec{CPUs: 4})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ = server.VM("PARENT")
+	if stored.PowerState != wsapitest.Suspended || stored.Processors != 4 {
+		t.Errorf("We should have changed the VM and suspended it again: %#v", stored)
+	}
+}
+
+func TestUpdateVMLegacy(t *testing.T) {
+	tests := []struct {
+		name   string
+		cpus   int32
+		state  string
+		resets int
+	}{
+		{"keep", 0, "", 0},
+		{"reset", 0, "reset", 1},
+		{"reset after a restart", 4, "reset", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vmc, server := newTestClient(t, runningVM())
+			err := New(vmc).UpdateVM(&MyVm{IdVM: "PARENT"}, "renamed", "", test.cpus, 0, test.state)
+			if err != nil {
+				t.Fatalf("%#v\n", err)
+			}
+			stored, _ := server.VM("PARENT")
+			if stored.DisplayName != "renamed" || stored.Annotation != "The parent VM" || stored.PowerState != wsapitest.PoweredOn {
+				t.Errorf("An empty description should keep the current one: %#v", stored)
+			}
+			var resets int
+			for _, request := range server.Requests() {
+				if request.Method == "PUT" && request.Body == "reset" {
+					resets++
+				}
+			}
+			if resets != test.resets {
+				t.Errorf("We have reset the VM %d times, we want %d", resets, test.resets)
+			}
+		})
+	}
+}
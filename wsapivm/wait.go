@@ -0,0 +1,165 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+)
+
+// These are the values that we use when the WaitOptions don't say anything.
+const (
+	DefaultWaitInterval    = time.Second
+	DefaultWaitMaxInterval = 10 * time.Second
+)
+
+// WaitOptions are the settings that we use to wait for a condition of a VM, after each
+// read of the API we double the interval until MaxInterval.
+// Interval: (time.Duration) Time between the first two reads, 0 means DefaultWaitInterval.
+// MaxInterval: (time.Duration) Maximum time between two reads, 0 means DefaultWaitMaxInterval.
+// Stable: (int) Number of consecutive reads that have to give us the same good value, 0 means 1.
+// Timeout: (time.Duration) Maximum time that we wait, 0 means that we only use the deadline of the context.
+type WaitOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Stable      int
+	Timeout     time.Duration
+}
+
+// WaitTimeoutError is the error that we have when the VM doesn't reach the condition
+// in time, it says what we saw the last time that we read the API.
+// IdVM: (string) The ID of the VM.
+// Condition: (string) What we were waiting for, like "power state on".
+// LastValue: (string) The last value that the API give us, empty if we never had one.
+// LastErr: (error) The error of the last read of the API, nil if it worked.
+// Polls: (int) Number of reads that we have made.
+// Elapsed: (time.Duration) Time that we have been waiting.
+// Err: (error) The reason to stop, context.DeadlineExceeded or context.Canceled.
+type WaitTimeoutError struct {
+	IdVM      string
+	Condition string
+	LastValue string
+	LastErr   error
+	Polls     int
+	Elapsed   time.Duration
+	Err       error
+}
+
+// Error method to implement the error interface.
+func (e *WaitTimeoutError) Error() string {
+	msg := fmt.Sprintf("wait for %s of VM %q: gave up after %s and %d polls, the last value was %q",
+		e.Condition, e.IdVM, e.Elapsed.Round(time.Millisecond), e.Polls, e.LastValue)
+	if e.LastErr != nil {
+		msg += fmt.Sprintf(" and the last error %q", e.LastErr.Error())
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap method return the reason to stop, so errors.Is(err, context.DeadlineExceeded) works.
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForPowerState method wait until the VM has the Power State that we want, it reads
+// the Power State with backoff until the context is done or the Timeout of the options.
+// Input:
+// ctx: (context.Context) The context that limit the wait.
+// vm: (*wsapivm.MyVM) The VM object that we are waiting for, we update its PowerStatus.
+// state: (PowerState) The Power State that we want.
+// opts: (...WaitOptions) Optional settings of the wait, we only use the first one.
+// Output:
+// error: (error) *WaitTimeoutError if the VM doesn't reach the state in time.
+func (vmm *VMManager) WaitForPowerState(ctx context.Context, vm *MyVm, state PowerState, opts ...WaitOptions) error {
+	_, err := vmm.poll(ctx, vm, "power state "+string(state), firstWaitOptions(opts), func(ctx context.Context) (string, bool, error) {
+		err := GetPowerStatusContext(ctx, vmm.vmclient, vm)
+		return vm.PowerStatus, PowerState(vm.PowerStatus) == state, err
+	})
+	return err
+}
+
+// WaitForIP method wait until the guest OS of the VM report an IP, with Stable greater
+// than 1 the IP has to be the same in all the reads, in this way we skip the temporal
+// IPs that the guest has while the DHCP is working.
+// Input:
+// ctx: (context.Context) The context that limit the wait.
+// vm: (*wsapivm.MyVM) The VM object that we are waiting for.
+// opts: (...WaitOptions) Optional settings of the wait, we only use the first one.
+// Output:
+// (string) The IP of the guest.
+// error: (error) *WaitTimeoutError if the guest doesn't have an IP in time.
+func (vmm *VMManager) WaitForIP(ctx context.Context, vm *MyVm, opts ...WaitOptions) (string, error) {
+	return vmm.poll(ctx, vm, "IP", firstWaitOptions(opts), func(ctx context.Context) (string, bool, error) {
+		ip, err := GetIPContext(ctx, vmm.vmclient, vm)
+		return ip, ip != "", err
+	})
+}
+
+// firstWaitOptions function return the first options of opts or the default ones if we don't have any.
+func firstWaitOptions(opts []WaitOptions) WaitOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return WaitOptions{}
+}
+
+// poll method call check until it give us a good value the number of times that opts.Stable
+// says, the errors of check mean that the VM isn't ready yet, except when the VM
+// doesn't exist or we don't have access to it.
+func (vmm *VMManager) poll(ctx context.Context, vm *MyVm, condition string, opts WaitOptions, check func(ctx context.Context) (string, bool, error)) (string, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWaitInterval
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = DefaultWaitMaxInterval
+	}
+	if opts.Stable <= 0 {
+		opts.Stable = 1
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	interval := opts.Interval
+	timeout := &WaitTimeoutError{IdVM: vm.IdVM, Condition: condition}
+	stable := 0
+	for {
+		value, ok, err := check(ctx)
+		timeout.Polls++
+		switch {
+		case err != nil && ctx.Err() == nil && (errors.Is(err, httpclient.ErrNotFound) || errors.Is(err, httpclient.ErrUnauthorized)):
+			return "", fmt.Errorf("wait for %s of VM %q: %w", condition, vm.IdVM, err)
+		case err != nil:
+			timeout.LastErr = err
+			stable = 0
+		case ok && stable > 0 && value == timeout.LastValue:
+			stable++
+		case ok:
+			stable = 1
+		default:
+			stable = 0
+		}
+		if err == nil {
+			timeout.LastValue = value
+			timeout.LastErr = nil
+		}
+		vmm.log().Debug().Msgf("We are waiting for %s of the VM %#v, we have %#v (%d of %d)", condition, vm.IdVM, timeout.LastValue, stable, opts.Stable)
+		if stable >= opts.Stable {
+			vmm.log().Info().Msgf("We have %s of the VM.", condition)
+			return value, nil
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			timeout.Elapsed = time.Since(start)
+			timeout.Err = ctx.Err()
+			return "", timeout
+		case <-timer.C:
+		}
+		interval = min(interval*2, opts.MaxInterval)
+	}
+}
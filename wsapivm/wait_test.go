@@ -0,0 +1,77 @@
+package wsapivm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+// fastWait are the WaitOptions that we use in the tests, to don't wait too much.
+var fastWait = WaitOptions{Interval: 5 * time.Millisecond, MaxInterval: 20 * time.Millisecond, Timeout: 300 * time.Millisecond}
+
+func TestWaitForPowerState(t *testing.T) {
+	vm := parentVM
+	vm.PowerState = wsapitest.PoweredOn
+	vm.ShutdownPolls = 3
+	vmc, server := newTestClient(t, vm)
+	vmm := New(vmc)
+	myvm := &MyVm{IdVM: "PARENT"}
+	err := PowerSwitch(vmc, myvm, "shutdown")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.WaitForPowerState(context.Background(), myvm, PowerStateOff, fastWait)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if myvm.PowerStatus != "off" || server.CountRequests("GET", "vms/PARENT/power") != 3 {
+		t.Errorf("We haven't waited the shutdown: %#v %#v", myvm, server.Requests())
+	}
+	err = vmm.WaitForPowerState(context.Background(), myvm, PowerStateOn, fastWait)
+	var timeout *WaitTimeoutError
+	if !errors.As(err, &timeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("The error should be a WaitTimeoutError: %#v", err)
+	}
+	if timeout.LastValue != "off" || timeout.Polls < 2 || timeout.Condition != "power state on" {
+		t.Errorf("The error doesn't describe the last state: %#v", timeout)
+	}
+}
+
+func TestWaitForIP(t *testing.T) {
+	vm := parentVM
+	vm.PowerState = wsapitest.PoweredOn
+	vmc, server := newTestClient(t, vm)
+	vmm := New(vmc)
+	myvm := &MyVm{IdVM: "PARENT"}
+	_, err := vmm.WaitForIP(context.Background(), myvm, fastWait)
+	var timeout *WaitTimeoutError
+	if !errors.As(err, &timeout) || timeout.LastErr == nil || timeout.LastValue != "" {
+		t.Fatalf("The guest hasn't an IP, the error should be a WaitTimeoutError with the last error: %#v", err)
+	}
+	// We use another VM because a read of the first wait can arrive late at the server
+	other := vm
+	other.ID = "OTHER"
+	other.IP = "192.168.1.10"
+	server.AddVM(other)
+	opts := fastWait
+	opts.Stable = 3
+	ip, err := vmm.WaitForIP(context.Background(), &MyVm{IdVM: "OTHER"}, opts)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if ip != "192.168.1.10" || server.CountRequests("GET", "vms/OTHER/ip") != 3 {
+		t.Errorf("We haven't waited a stable IP: %#v %#v", ip, server.Requests())
+	}
+	ip, err = vmm.WaitForIP(context.Background(), &MyVm{IdVM: "OTHER"})
+	if err != nil || ip != "192.168.1.10" {
+		t.Errorf("We should wait with the default options: %#v %#v", ip, err)
+	}
+	_, err = vmm.WaitForIP(context.Background(), &MyVm{IdVM: "MISSING"}, fastWait)
+	if !errors.Is(err, httpclient.ErrNotFound) || errors.As(err, &timeout) {
+		t.Errorf("A missing VM should stop the wait with ErrNotFound: %#v", err)
+	}
+}
@@ -2,93 +2,310 @@ package wsapivm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 // New functon is just to create a new object HTTP Client to make the different calls at VmWare Workstation Pro
-func New(httpcaller *httpclient.HTTPClient) VMService {
-	return &VMManager{vmclient: httpcaller}
+// opts: (...Option) Optional settings of the manager, like the logger.
+func New(httpcaller *httpclient.HTTPClient, opts ...Option) VMService {
+	vmm := &VMManager{vmclient: httpcaller}
+	for _, opt := range opts {
+		opt(vmm)
+	}
+	return vmm
+}
+
+// WithLogger option set the logger of the manager, if we don't set it
+// the manager use the same logger that the HTTP client.
+// Inputs:
+// l: (zerolog.Logger) The logger that we want to use.
+func WithLogger(l zerolog.Logger) Option {
+	return func(vmm *VMManager) {
+		vmm.SetLogger(&l)
+	}
+}
+
+// These are the values that we use when the StopPolicy doesn't say anything.
+const (
+	DefaultStopTimeout      = 2 * time.Minute
+	DefaultStopPollInterval = 2 * time.Second
+)
+
+// WithStopPolicy option set the way that the manager stop the VMs before
+// UpdateVM and DeleteVM, by default we use StopGracefulThenHard.
+// Inputs:
+// p: (StopPolicy) The policy that we want to use.
+func WithStopPolicy(p StopPolicy) Option {
+	return func(vmm *VMManager) {
+		vmm.SetStopPolicy(p)
+	}
+}
+
+// SetStopPolicy method change the way that the manager stop the VMs.
+// Inputs:
+// p: (StopPolicy) The policy that we want to use.
+func (vmm *VMManager) SetStopPolicy(p StopPolicy) {
+	vmm.stopPolicy = p
+}
+
+// DefaultConcurrency is the number of VMs that GetAllVMs load at the same time if we don't say anything.
+const DefaultConcurrency = 4
+
+// WithGuestNetwork option make that LoadVM, LoadVMbyName and GetAllVMs fill the
+// network information of the guest OS in the VMs that are on, it needs two API
+// calls more for each VM so by default we don't do it.
+func WithGuestNetwork() Option {
+	return func(vmm *VMManager) {
+		vmm.SetGuestNetwork(true)
+	}
+}
+
+// SetGuestNetwork method enable or disable the network information of the guest
+// OS in LoadVM, LoadVMbyName and GetAllVMs.
+// Inputs:
+// enabled: (bool) True if we want the network information.
+func (vmm *VMManager) SetGuestNetwork(enabled bool) {
+	if enabled {
+		vmm.fields = vmm.loadFields() | FieldGuestNetwork
+	} else {
+		vmm.fields = vmm.loadFields() &^ FieldGuestNetwork
+	}
+}
+
+// DefaultRollbackTimeout is the maximum time that we use to delete a VM that we couldn't create.
+const DefaultRollbackTimeout = 2 * time.Minute
+
+// WithoutRollback option make that Create and CreateVM keep the VM when something goes
+// wrong after the clone, by default we delete it. It's useful to see what happened.
+func WithoutRollback() Option {
+	return func(vmm *VMManager) {
+		vmm.SetRollback(false)
+	}
 }
 
-// GetAllVMs Method return array of MyVm and a error variable if occur some problem
+// SetRollback method enable or disable the deletion of the VMs that Create and CreateVM
+// couldn't finish.
+// Inputs:
+// enabled: (bool) True if we want to delete them, that's the default.
+func (vmm *VMManager) SetRollback(enabled bool) {
+	vmm.keepFailed = !enabled
+}
+
+// WithFields option choose the values that LoadVM, LoadVMbyName, LoadVMbyPath and GetAllVMs
+// load, the ID and the Path are always loaded. By default we use DefaultFields.
+// Inputs:
+// f: (VMField) The values that we want, like FieldBasicInfo | FieldPowerState.
+func WithFields(f VMField) Option {
+	return func(vmm *VMManager) {
+		vmm.SetFields(f)
+	}
+}
+
+// SetFields method change the values that the manager load, 0 means DefaultFields.
+// Inputs:
+// f: (VMField) The values that we want.
+func (vmm *VMManager) SetFields(f VMField) {
+	vmm.fields = f
+}
+
+// WithConcurrency option set the number of VMs that GetAllVMs load at the same time,
+// by default DefaultConcurrency.
+// Inputs:
+// n: (int) The number of VMs, 1 means one after the other.
+func WithConcurrency(n int) Option {
+	return func(vmm *VMManager) {
+		vmm.SetConcurrency(n)
+	}
+}
+
+// SetConcurrency method change the number of VMs that GetAllVMs load at the same time,
+// 0 or less means DefaultConcurrency.
+// Inputs:
+// n: (int) The number of VMs.
+func (vmm *VMManager) SetConcurrency(n int) {
+	vmm.concurrency = n
+}
+
+// loadFields method return the values that the manager has to load.
+func (vmm *VMManager) loadFields() VMField {
+	if vmm.fields == 0 {
+		return DefaultFields
+	}
+	return vmm.fields
+}
+
+// extraParameters method fill the VM with all the values that the manager has to load.
+func (vmm *VMManager) extraParameters(ctx context.Context, vm *MyVm) error {
+	fields := vmm.loadFields()
+	if fields&FieldBasicInfo != 0 {
+		err := GetBasicInfoContext(ctx, vmm.vmclient, vm)
+		if err != nil {
+			return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
+		}
+	}
+	if fields&FieldDenominationDescription != 0 {
+		err := GetDenominationDescriptionContext(ctx, vmm.vmclient, vm)
+		if err != nil {
+			return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
+		}
+	}
+	if fields&(FieldPowerState|FieldGuestNetwork) != 0 {
+		err := GetPowerStatusContext(ctx, vmm.vmclient, vm)
+		if err != nil {
+			return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
+		}
+	}
+	if fields&FieldGuestNetwork != 0 {
+		err := GetGuestNetworkContext(ctx, vmm.vmclient, vm)
+		if err != nil {
+			return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
+		}
+	}
+	return nil
+}
+
+// SetLogger method change the logger of the manager, nil means that we
+// go back to the logger of the HTTP client.
+// Inputs:
+// l: (*zerolog.Logger) The logger that we want to use.
+func (vmm *VMManager) SetLogger(l *zerolog.Logger) {
+	vmm.logger = l
+}
+
+// log method return the logger that the manager has to use.
+func (vmm *VMManager) log() *zerolog.Logger {
+	if vmm.logger != nil {
+		return vmm.logger
+	}
+	return vmm.vmclient.Log()
+}
+
+// GetAllVMs Method return array of MyVm and a error variable if occur some problem, we load
+// the values of several VMs at the same time, see WithConcurrency and WithFields.
 // Outputs:
 // []MyVm list of all VMs that we have in VmWare Workstation
-// (error) variable with the error if occur
+// (error) variable with the error if occur, *PartialError if we couldn't load some VMs,
+// in this case the list has the rest of them
 func (vmm *VMManager) GetAllVMs() ([]MyVm, error) {
+	return vmm.GetAllVMsContext(context.Background())
+}
+
+// GetAllVMsContext is the same as GetAllVMs but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) GetAllVMsContext(ctx context.Context) ([]MyVm, error) {
 	var vms []MyVm
-	responseBody, err := vmm.vmclient.ApiCall("vms", "GET", bytes.Buffer{})
+	responseBody, err := vmm.vmclient.ApiCallContext(ctx, "vms", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We can't made the API call.")
-		return nil, err
+		return nil, fmt.Errorf("get all VMs: %w", err)
 	}
-	log.Debug().Msgf("Response Body RAW: %#v", responseBody)
+	vmm.log().Debug().Msgf("Response Body RAW: %#v", responseBody)
 	err = json.NewDecoder(responseBody).Decode(&vms)
 	if err != nil {
-		log.Error().Err(err).Msg("The JSON was malformed")
-		return nil, err
+		return nil, fmt.Errorf("get all VMs: decoding response: %w", err)
 	}
-	log.Info().Str("NumOfVMs", strconv.Itoa(len(vms))).Msg("You have this amount of VM in you Workstation")
-	for pos, item := range vms {
-		// --------- This Block read the ID of the VM --------- {{{
-		err = GetAllExtraParameters(vmm.vmclient, &item)
-		if err != nil {
-			log.Error().Err(err).Msg("We couldn't get all the extra parameters.")
-			return nil, err
+	vmm.log().Info().Str("NumOfVMs", strconv.Itoa(len(vms))).Msg("You have this amount of VM in you Workstation")
+	errs := vmm.loadAll(ctx, vms)
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("get all VMs: %w", ctx.Err())
+	}
+	if len(errs) > 0 {
+		loaded := make([]MyVm, 0, len(vms)-len(errs))
+		partial := &PartialError{Total: len(vms)}
+		for pos, item := range vms {
+			if err, failed := errs[pos]; failed {
+				partial.Errors = append(partial.Errors, &VMError{IdVM: item.IdVM, Err: err})
+			} else {
+				loaded = append(loaded, item)
+			}
 		}
-		vms[pos] = item
-		log.Debug().Msgf("The VM loaded is:: %#v", item)
+		vmm.log().Warn().Msgf("We have listed %d VMs, but we couldn't load %d of them", len(loaded), len(partial.Errors))
+		return loaded, fmt.Errorf("get all VMs: %w", partial)
 	}
-	log.Info().Msg("We have listed all VMs")
+	vmm.log().Info().Msg("We have listed all VMs")
 	return vms, nil
 }
 
-// CreateVM method to create a new VM in VmWare Worstation
+// loadAll method load the values of all the VMs with a pool of workers, it give us
+// the error of each VM in the same position, nil if there isn't any error.
+func (vmm *VMManager) loadAll(ctx context.Context, vms []MyVm) map[int]error {
+	return vmm.forEach(ctx, vms, func(ctx context.Context, vm *MyVm) error {
+		err := vmm.extraParameters(ctx, vm)
+		if err == nil {
+			vmm.log().Debug().Msgf("The VM loaded is:: %#v", vm)
+		}
+		return err
+	})
+}
+
+// forEach method call fn with all the VMs using a pool of workers, the size of the pool
+// is the concurrency of the manager. It give us the errors of fn by position of the VM.
+func (vmm *VMManager) forEach(ctx context.Context, vms []MyVm, fn func(ctx context.Context, vm *MyVm) error) map[int]error {
+	workers := vmm.concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	workers = min(workers, len(vms))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[int]error)
+	jobs := make(chan int)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pos := range jobs {
+				err := fn(ctx, &vms[pos])
+				if err != nil {
+					mu.Lock()
+					errs[pos] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for pos := range vms {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- pos
+	}
+	close(jobs)
+	wg.Wait()
+	return errs
+}
+
+// CreateVM method to create a new VM in VmWare Worstation, it's the same as Create
+// with a CreateVMSpec, so if something goes wrong after the clone we delete it.
 // Input:
 // pid: (string) with the ID of the Parent VM,
 // n: string with the denomination of the VM,
 // d: string with the description of VM
 // p: int with the number of processors in the VM
 // m: int with the number of memory in the VM
-// s: (string) this will be the state of the VM in creation (on, off, restart)
+// s: (string) this will be the state of the VM in creation (on, off)
 func (vmm *VMManager) CreateVM(pid string, n string, d string, p int32, m int32, s string) (*MyVm, error) {
-	vm, err := CloneVM(vmm.vmclient, pid, n)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't Clone the VM.")
-		return nil, err
-	}
-	log.Debug().Msgf("The Clone VM is: %#v", vm)
-	err = SetBasicInfo(vmm.vmclient, vm, p, m)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't change the settings of VM.")
-		return nil, err
-	}
-	log.Debug().Msgf("We have put %#v processors and %#v memory in %#v VM", p, m, vm.Denomination)
-	err = PowerSwitch(vmm.vmclient, vm, s)
-	if err != nil {
-		log.Error().Err(err).Msg("We can't change the PowerState of VM.")
-		return nil, err
-	}
-	log.Debug().Msgf("We have Changed the state of VM to: %#v", s)
-	// We need to wait after the VmWare Workstation Team fix the API {{{
-	// err = SetParameter(vmm.vmclient, vm, "denomination", n)
-	// if err != nil {
-	// 	log.Error().Err(err).Msg("We can't change the Denomination of VM.")
-	// 	return nil, err
-	// }
-	// log.Debug().Msgf("We have put %#v as name of %#v VM", n, vm.Denomination)
-	// err = SetParameter(vmm.vmclient, vm, "description", d)
-	// if err != nil {
-	// 	log.Error().Err(err).Msg("We can't change the Description of VM.")
-	// 	return nil, err
-	// }
-	// log.Debug().Msgf("We have put %#v as description of %#v VM", d, vm.Denomination)
-	// }}}
-	log.Info().Msg("We have created the VM.")
-	return vm, nil
+	return vmm.CreateVMContext(context.Background(), pid, n, d, p, m, s)
+}
+
+// CreateVMContext is the same as CreateVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) CreateVMContext(ctx context.Context, pid string, n string, d string, p int32, m int32, s string) (*MyVm, error) {
+	return vmm.CreateContext(ctx, CreateVMSpec{
+		ParentID:    pid,
+		DisplayName: n,
+		Description: d,
+		CPUs:        p,
+		Memory:      m,
+		PowerState:  PowerState(s),
+	})
 }
 
 // LoadVM method return the object MyVm with the ID indicate in i.
@@ -98,18 +315,22 @@ func (vmm *VMManager) CreateVM(pid string, n string, d string, p int32, m int32,
 // (pointer) Pointer at the MyVm object
 // (error) variable with the error if occur
 func (vmm *VMManager) LoadVM(i string) (*MyVm, error) {
-	vm, err := GetVM(vmm.vmclient, i)
+	return vmm.LoadVMContext(context.Background(), i)
+}
+
+// LoadVMContext is the same as LoadVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) LoadVMContext(ctx context.Context, i string) (*MyVm, error) {
+	vm, err := vmm.findVM(ctx, keyID, i)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't show the ID and Path.")
-		return nil, err
+		return nil, fmt.Errorf("load VM %q: %w", i, err)
 	}
-	err = GetAllExtraParameters(vmm.vmclient, vm)
+	err = vmm.extraParameters(ctx, vm)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't get all the extra parameters.")
-		return nil, err
+		return nil, fmt.Errorf("load VM %q: %w", i, err)
 	}
-	log.Debug().Msgf("The ID that we are trying to load is: %#v", i)
-	log.Info().Msg("We have loaded the VM.")
+	vmm.log().Debug().Msgf("The ID that we are trying to load is: %#v", i)
+	vmm.log().Info().Msg("We have loaded the VM.")
 	return vm, err
 }
 
@@ -120,84 +341,84 @@ func (vmm *VMManager) LoadVM(i string) (*MyVm, error) {
 // (pointer) Pointer at the MyVm object
 // (error) variable with the error if occur
 func (vmm *VMManager) LoadVMbyName(n string) (*MyVm, error) {
-	vm, err := GetVMbyName(vmm.vmclient, n)
+	return vmm.LoadVMbyNameContext(context.Background(), n)
+}
+
+// LoadVMbyNameContext is the same as LoadVMbyName but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) LoadVMbyNameContext(ctx context.Context, n string) (*MyVm, error) {
+	vm, err := vmm.findVM(ctx, keyName, n)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't show the ID and Path.")
-		return nil, err
+		return nil, fmt.Errorf("load VM by name %q: %w", n, err)
 	}
-	err = GetAllExtraParameters(vmm.vmclient, vm)
+	err = vmm.extraParameters(ctx, vm)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't get all the extra parameters.")
-		return nil, err
+		return nil, fmt.Errorf("load VM by name %q: %w", n, err)
 	}
-	log.Debug().Msgf("The ID that we are trying to load is: %#v", n)
-	log.Info().Msg("We have loaded the VM.")
+	vmm.log().Debug().Msgf("The ID that we are trying to load is: %#v", n)
+	vmm.log().Info().Msg("We have loaded the VM.")
 	return vm, err
 }
 
-// UpdateVM method to update a VM in VmWare Worstation
+// LoadVMbyPath method return the object MyVm with the path of the .vmx file indicate in p.
+// Inputs:
+// p: (string) String with the path of the .vmx file of the VM
+// Outputs:
+// (pointer) Pointer at the MyVm object
+// (error) variable with the error if occur
+func (vmm *VMManager) LoadVMbyPath(p string) (*MyVm, error) {
+	return vmm.LoadVMbyPathContext(context.Background(), p)
+}
+
+// LoadVMbyPathContext is the same as LoadVMbyPath but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) LoadVMbyPathContext(ctx context.Context, p string) (*MyVm, error) {
+	vm, err := vmm.findVM(ctx, keyPath, p)
+	if err != nil {
+		return nil, fmt.Errorf("load VM by path %q: %w", p, err)
+	}
+	err = vmm.extraParameters(ctx, vm)
+	if err != nil {
+		return nil, fmt.Errorf("load VM by path %q: %w", p, err)
+	}
+	vmm.log().Debug().Msgf("The path that we are trying to load is: %#v", p)
+	vmm.log().Info().Msg("We have loaded the VM.")
+	return vm, nil
+}
+
+// UpdateVM method to update a VM in VmWare Worstation, it's the same as Update, so we
+// only stop the VM when we change the processors or the memory.
 // Input:
 // vm (*MyVm) The VM that we want to update
-// n: string with the denomination of VM
-// d: string with the description of the VM, p: int with the number of processors
+// n: string with the denomination of VM, empty means that we keep it
+// d: string with the description of the VM, empty means that we keep it
+// p: int with the number of processors
 // m: int with the size of memory
-// s: Power State desired, choose between on, off, reset, (nil no change)
+// s: Power State desired, choose between on, off, reset to restart it with the changes, (empty no change)
 // Output:
 // pointer at the MyVm object
 // and error variable with the error if occur
 func (vmm *VMManager) UpdateVM(vm *MyVm, n string, d string, p int32, m int32, s string) error {
-	var buffer bytes.Buffer
-	var memcpu SettingPayload
-	var currentPowerStatus string
-	memcpu.Processors = p
-	memcpu.Memory = m
-	log.Debug().Msgf("State of VM before to update: %#v", vm)
-	if s == "" {
-		currentPowerStatus = vm.PowerStatus
-		log.Debug().Msgf("The Current Power Status was %#v", currentPowerStatus)
-	} else {
-		currentPowerStatus = s
-		log.Debug().Msgf("We want to change the current Power Status at %#v", currentPowerStatus)
-	}
-	// Here we are preparing the update of the Processors and Memory in the VM {{{
-	err := PowerSwitch(vmm.vmclient, vm, "off")
-	if err != nil {
-		log.Error().Err(err).Msgf("We can't shutdown the VM")
-		return err
-	}
-	request, err := json.Marshal(memcpu)
-	if err != nil {
-		log.Error().Err(err).Msgf("Trying to encode this request: %#v", memcpu)
-		return err
-	}
-	buffer.Write(request)
-	log.Debug().Msgf("Request Buffer: %#v", buffer.String())
-	_, err = vmm.vmclient.ApiCall("vms/"+vm.IdVM, "PUT", buffer)
-	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+	return vmm.UpdateVMContext(context.Background(), vm, n, d, p, m, s)
+}
+
+// UpdateVMContext is the same as UpdateVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) UpdateVMContext(ctx context.Context, vm *MyVm, n string, d string, p int32, m int32, s string) error {
+	spec := UpdateVMSpec{DisplayName: n, CPUs: p, Memory: m, PowerState: PowerState(s)}
+	if d != "" {
+		spec.Description = &d
 	}
-	err = PowerSwitch(vmm.vmclient, vm, currentPowerStatus)
-	if err != nil {
-		log.Error().Err(err).Msgf("We can't complete the Shutdown/PowerOn operation")
-		return err
+	reset := PowerOperation(s) == PowerOperationReset
+	if reset {
+		spec.PowerState = PowerStateOn
 	}
-	// ---- here we have to implement the code to update de description and denomination {{{
-	// here you will need to use the API to change the values of the Denomination and Description
-	// }}}
-	err = GetBasicInfo(vmm.vmclient, vm)
-	if err != nil {
-		log.Error().Err(err).Msg("We couldn't get information.")
+	report, err := vmm.UpdateContext(ctx, vm, spec)
+	if err != nil || !reset || report.Restarted || report.has(ChangePowerState) {
 		return err
 	}
-	err = GetDenominationDescription(vmm.vmclient, vm)
-	if err != nil {
-		log.Error().Err(err).Msg("We couldn't get information.")
-		return err
-	}
-	log.Debug().Msgf("State of VM after to update: %#v", vm)
-	log.Info().Msg("We have updated the VM.")
-	return err
+	// The VM hasn't been started by the update, so we restart it like it was asked
+	return vmm.ResetContext(ctx, vm)
 }
 
 // RegisterVM method to register a new VM in VmWare Worstation GUI:
@@ -207,36 +428,39 @@ func (vmm *VMManager) UpdateVM(vm *MyVm, n string, d string, p int32, m int32, s
 // Output:
 // error: (error) The possible error that you will have.
 func (vmm *VMManager) RegisterVM(vm *MyVm) error {
+	return vmm.RegisterVMContext(context.Background(), vm)
+}
+
+// RegisterVMContext is the same as RegisterVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) RegisterVMContext(ctx context.Context, vm *MyVm) error {
+	defer vmm.invalidate()
 	var regvm RegisterPayload
 	regvm.Name = vm.Denomination
 	regvm.Path = vm.Path
 	requestBody := new(bytes.Buffer)
 	request, err := json.Marshal(regvm)
 	if err != nil {
-		log.Error().Err(err).Msgf("Trying to encode this request: %#v", regvm)
-		return err
+		return fmt.Errorf("register VM %q: encoding request: %w", vm.Path, err)
 	}
 	requestBody.Write(request)
-	log.Debug().Msgf("Request Human Readable: %#v", requestBody.String())
-	response, err := vmm.vmclient.ApiCall("vms/registration", "POST", *requestBody)
+	vmm.log().Debug().Msgf("Request Human Readable: %#v", vmm.vmclient.Redact(requestBody.String()))
+	response, err := vmm.vmclient.ApiCallContext(ctx, "vms/registration", "POST", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("register VM %q: %w", vm.Path, err)
 	}
-	log.Debug().Msgf("Response: %#v", response)
+	vmm.log().Debug().Msgf("Response: %#v", response)
 	responseBody := new(bytes.Buffer)
 	_, err = responseBody.ReadFrom(response)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("register VM %q: decoding response: %w", vm.Path, err)
 	}
-	log.Debug().Msgf("Response Human Readable: %#v", responseBody.String())
+	vmm.log().Debug().Msgf("Response Human Readable: %#v", vmm.vmclient.Redact(responseBody.String()))
 	err = json.NewDecoder(responseBody).Decode(&vm)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("register VM %q: decoding response: %w", vm.Path, err)
 	}
-	log.Info().Msg("We have registered the VM in GUI.")
+	vmm.log().Info().Msg("We have registered the VM in GUI.")
 	return err
 }
 
@@ -247,23 +471,187 @@ func (vmm *VMManager) RegisterVM(vm *MyVm) error {
 // Output:
 // error: (error) The possible error that you will have.
 func (vmm *VMManager) DeleteVM(vm *MyVm) error {
-	err := PowerSwitch(vmm.vmclient, vm, "off")
+	return vmm.DeleteVMContext(context.Background(), vm)
+}
+
+// DeleteVMContext is the same as DeleteVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) DeleteVMContext(ctx context.Context, vm *MyVm) error {
+	defer vmm.invalidate()
+	// We are going to delete the VM, so we don't care about the state of a suspended VM
+	err := vmm.stop(ctx, vm, true)
 	if err != nil {
-		log.Error().Err(err).Msgf("We can't shutdown the VM")
-		return err
+		return fmt.Errorf("delete VM %q: %w", vm.IdVM, err)
 	}
-	response, err := vmm.vmclient.ApiCall("vms/"+vm.IdVM, "DELETE", bytes.Buffer{})
+	return vmm.remove(ctx, vm)
+}
+
+// remove method delete the files of the VM, the API also remove it of the list of
+// VMs, so we don't need to unregister it. The VM has to be off.
+func (vmm *VMManager) remove(ctx context.Context, vm *MyVm) error {
+	response, err := vmm.vmclient.ApiCallContext(ctx, "vms/"+vm.IdVM, "DELETE", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("delete VM %q: %w", vm.IdVM, err)
 	}
 	responseBody := new(bytes.Buffer)
 	_, err = responseBody.ReadFrom(response)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
+		return fmt.Errorf("delete VM %q: decoding response: %w", vm.IdVM, err)
+	}
+	vmm.log().Debug().Msgf("Response Human Readable: %#v", vmm.vmclient.Redact(responseBody.String()))
+	vmm.log().Info().Msg("We have deleted the VM.")
+	return nil
+}
+
+// Power method change the Power State of the VM with the operation op, if the VM
+// is already in the Power State that we want we don't make the call.
+// Input:
+// vm: (*wsapivm.MyVM) The VM object that we want to change.
+// op: (PowerOperation) The operation, like PowerOperationSuspend.
+// Output:
+// error: (error) The possible error that you will have.
+func (vmm *VMManager) Power(vm *MyVm, op PowerOperation) error {
+	return vmm.PowerContext(context.Background(), vm, op)
+}
+
+// PowerContext is the same as Power but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) PowerContext(ctx context.Context, vm *MyVm, op PowerOperation) error {
+	err := PowerOperateContext(ctx, vmm.vmclient, vm, op)
+	if err != nil {
 		return err
 	}
-	log.Debug().Msgf("Response Human Readable: %#v", responseBody.String())
-	log.Info().Msg("We have deleted the VM.")
+	vmm.log().Info().Msgf("The VM %#v is %#v.", vm.IdVM, vm.PowerStatus)
+	return nil
+}
+
+// Suspend method suspend the VM, it does nothing if the VM is already suspended.
+func (vmm *VMManager) Suspend(vm *MyVm) error {
+	return vmm.PowerContext(context.Background(), vm, PowerOperationSuspend)
+}
+
+// SuspendContext is the same as Suspend but the API calls are bound to ctx.
+func (vmm *VMManager) SuspendContext(ctx context.Context, vm *MyVm) error {
+	return vmm.PowerContext(ctx, vm, PowerOperationSuspend)
+}
+
+// Pause method pause the VM, it does nothing if the VM is already paused.
+func (vmm *VMManager) Pause(vm *MyVm) error {
+	return vmm.PowerContext(context.Background(), vm, PowerOperationPause)
+}
+
+// PauseContext is the same as Pause but the API calls are bound to ctx.
+func (vmm *VMManager) PauseContext(ctx context.Context, vm *MyVm) error {
+	return vmm.PowerContext(ctx, vm, PowerOperationPause)
+}
+
+// Unpause method resume a paused VM, it does nothing if the VM is already running.
+func (vmm *VMManager) Unpause(vm *MyVm) error {
+	return vmm.PowerContext(context.Background(), vm, PowerOperationUnpause)
+}
+
+// UnpauseContext is the same as Unpause but the API calls are bound to ctx.
+func (vmm *VMManager) UnpauseContext(ctx context.Context, vm *MyVm) error {
+	return vmm.PowerContext(ctx, vm, PowerOperationUnpause)
+}
+
+// Shutdown method ask the guest OS to shut down, it does nothing if the VM is already off.
+func (vmm *VMManager) Shutdown(vm *MyVm) error {
+	return vmm.PowerContext(context.Background(), vm, PowerOperationShutdown)
+}
+
+// ShutdownContext is the same as Shutdown but the API calls are bound to ctx.
+func (vmm *VMManager) ShutdownContext(ctx context.Context, vm *MyVm) error {
+	return vmm.PowerContext(ctx, vm, PowerOperationShutdown)
+}
+
+// Reset method restart the VM, this operation is always made.
+func (vmm *VMManager) Reset(vm *MyVm) error {
+	return vmm.PowerContext(context.Background(), vm, PowerOperationReset)
+}
+
+// ResetContext is the same as Reset but the API calls are bound to ctx.
+func (vmm *VMManager) ResetContext(ctx context.Context, vm *MyVm) error {
+	return vmm.PowerContext(ctx, vm, PowerOperationReset)
+}
+
+// StopVM method stop the VM with the StopPolicy of the manager, by default we ask
+// the guest OS to shut down and, if it doesn't do it in time, we cut the power. We
+// don't stop the suspended or paused VMs unless the policy has DiscardState, because
+// we would lose the state of their memory.
+// Input:
+// vm: (*wsapivm.MyVM) The VM object that we want to stop.
+// Output:
+// error: (error) The possible error that you will have, ErrInvalidState if the VM is suspended or paused.
+func (vmm *VMManager) StopVM(vm *MyVm) error {
+	return vmm.StopVMContext(context.Background(), vm)
+}
+
+// StopVMContext is the same as StopVM but the API calls and the wait are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func (vmm *VMManager) StopVMContext(ctx context.Context, vm *MyVm) error {
+	return vmm.stop(ctx, vm, vmm.stopPolicy.DiscardState)
+}
+
+// stop method stop the VM with the StopPolicy, discard says if we can cut the power
+// of a suspended or paused VM.
+func (vmm *VMManager) stop(ctx context.Context, vm *MyVm, discard bool) error {
+	policy := vmm.stopPolicy
+	if policy.Timeout == 0 {
+		policy.Timeout = DefaultStopTimeout
+	}
+	if policy.PollInterval == 0 {
+		policy.PollInterval = DefaultStopPollInterval
+	}
+	err := GetPowerStatusContext(ctx, vmm.vmclient, vm)
+	if err != nil {
+		return fmt.Errorf("stop VM %q: %w", vm.IdVM, err)
+	}
+	vmm.log().Debug().Msgf("We are stopping the VM %#v, its Power State is %#v", vm.IdVM, vm.PowerStatus)
+	switch {
+	case PowerState(vm.PowerStatus) == PowerStateOff:
+		vmm.log().Info().Msg("The VM is already stopped.")
+		return nil
+	case PowerState(vm.PowerStatus) != PowerStateOn && !discard:
+		return fmt.Errorf("stop VM %q: %w: it's %s and we would lose its state, use DiscardState in the StopPolicy", vm.IdVM, ErrInvalidState, vm.PowerStatus)
+	case policy.Mode == StopHard, PowerState(vm.PowerStatus) != PowerStateOn:
+		// A suspended or paused guest can't attend a shutdown, so we cut the power
+		return vmm.hardStop(ctx, vm)
+	}
+	err = vmm.gracefulStop(ctx, vm, policy)
+	if err == nil || policy.Mode == StopGraceful || ctx.Err() != nil {
+		return err
+	}
+	vmm.log().Warn().Msgf("We haven't been able to shut down the guest of the VM %#v, we are going to cut the power: %s", vm.IdVM, err)
+	return vmm.hardStop(ctx, vm)
+}
+
+// gracefulStop method ask the guest OS to shut down and wait until the VM is off or the Timeout of the policy.
+func (vmm *VMManager) gracefulStop(ctx context.Context, vm *MyVm, policy StopPolicy) error {
+	err := PowerSwitchContext(ctx, vmm.vmclient, vm, string(PowerOperationShutdown))
+	if err != nil {
+		return fmt.Errorf("stop VM %q: %w", vm.IdVM, err)
+	}
+	if PowerState(vm.PowerStatus) != PowerStateOff {
+		err = vmm.WaitForPowerState(ctx, vm, PowerStateOff, WaitOptions{
+			Interval:    policy.PollInterval,
+			MaxInterval: policy.PollInterval,
+			Timeout:     policy.Timeout,
+		})
+		if err != nil {
+			return fmt.Errorf("stop VM %q: %w", vm.IdVM, err)
+		}
+	}
+	vmm.log().Info().Msg("The guest of the VM has shut down.")
+	return nil
+}
+
+// hardStop method cut the power of the VM.
+func (vmm *VMManager) hardStop(ctx context.Context, vm *MyVm) error {
+	err := PowerSwitchContext(ctx, vmm.vmclient, vm, string(PowerOperationOff))
+	if err != nil {
+		return fmt.Errorf("stop VM %q: %w", vm.IdVM, err)
+	}
+	vmm.log().Info().Msg("We have cut the power of the VM.")
 	return nil
 }
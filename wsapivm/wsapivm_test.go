@@ -0,0 +1,311 @@
+package wsapivm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
+
+func TestGetAllVMs(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM, wsapitest.VM{ID: "OTHER", DisplayName: "other", PowerState: wsapitest.PoweredOn})
+	vms, err := New(vmc).GetAllVMs()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(vms) != 2 || vms[0].Denomination != "parent" || vms[1].PowerStatus != "on" {
+		t.Errorf("We haven't listed all the VMs: %#v", vms)
+	}
+}
+
+func TestGetAllVMsConcurrency(t *testing.T) {
+	var vms []wsapitest.VM
+	for i := range 10 {
+		vms = append(vms, wsapitest.VM{ID: fmt.Sprintf("VM%02d", i), DisplayName: fmt.Sprintf("vm%02d", i)})
+	}
+	server := wsapitest.NewServer(vms...)
+	t.Cleanup(server.Close)
+	server.On("GET", "vms/*").Delay(20 * time.Millisecond)
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	counter := func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+			return next.RoundTrip(req)
+		})
+	}
+	vmc, err := httpclient.NewClient(server.URL, server.User, server.Password, false, "NONE", httpclient.WithMiddleware(counter))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	loaded, err := New(vmc, WithConcurrency(3)).GetAllVMs()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(loaded) != 10 || loaded[0].IdVM != "VM00" || loaded[9].Denomination != "vm09" {
+		t.Errorf("We haven't loaded all the VMs in order: %#v", loaded)
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("We want at most 3 API calls at the same time, we have had %d", maxInFlight)
+	}
+}
+
+func TestGetAllVMsPartial(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM, wsapitest.VM{ID: "BROKEN", DisplayName: "broken"}, wsapitest.VM{ID: "OTHER", DisplayName: "other"})
+	server.On("GET", "vms/BROKEN/power").Busy()
+	loaded, err := New(vmc).GetAllVMs()
+	var partial *PartialError
+	if !errors.As(err, &partial) || partial.Total != 3 || len(partial.Errors) != 1 || partial.Errors[0].IdVM != "BROKEN" {
+		t.Fatalf("The error should be a PartialError with the broken VM: %#v", err)
+	}
+	if !errors.Is(err, httpclient.ErrVMBusy) {
+		t.Errorf("We should see the error of the broken VM: %#v", err)
+	}
+	if len(loaded) != 2 || loaded[0].IdVM != "PARENT" || loaded[1].IdVM != "OTHER" {
+		t.Errorf("We want the VMs that we have loaded: %#v", loaded)
+	}
+}
+
+func TestGetAllVMsFields(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	loaded, err := New(vmc, WithFields(FieldPowerState)).GetAllVMs()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if server.CountRequests("GET", "vms/PARENT") != 0 || server.CountRequests("GET", "vms/PARENT/params/displayName") != 0 {
+		t.Errorf("We only want the Power State: %#v", server.Requests())
+	}
+	if len(loaded) != 1 || loaded[0].PowerStatus != "off" || loaded[0].Memory != 0 {
+		t.Errorf("We haven't loaded only the Power State: %#v", loaded)
+	}
+}
+
+func TestCreateVM(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vm, err := New(vmc).CreateVM("PARENT", "clone", "The clone", 1, 1024, "on")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, ok := server.VM(vm.IdVM)
+	if !ok || stored.Processors != 1 || stored.Memory != 1024 || stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("The VM hasn't been created with the settings: %#v", stored)
+	}
+	_, err = New(vmc).CreateVM("MISSING", "clone", "The clone", 1, 1024, "on")
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+}
+
+func TestLoadVM(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM)
+	vm, err := New(vmc).LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.Path != "/vmware/parent/parent.vmx" || vm.Denomination != "parent" || vm.Memory != 2048 {
+		t.Errorf("We haven't loaded the VM: %#v", vm)
+	}
+	vm, err = New(vmc).LoadVMbyName("parent")
+	if err != nil || vm.IdVM != "PARENT" {
+		t.Errorf("We haven't loaded the VM by name: %#v %#v", vm, err)
+	}
+}
+
+func TestLoadVMbyPath(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM)
+	vmm := New(vmc)
+	vm, err := vmm.LoadVMbyPath("/vmware/parent/parent.vmx")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.IdVM != "PARENT" || vm.Denomination != "parent" || vm.Memory != 2048 {
+		t.Errorf("We haven't loaded the VM: %#v", vm)
+	}
+	_, err = vmm.LoadVM("MISSING")
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+}
+
+func TestLoadVMWithGuestNetwork(t *testing.T) {
+	vm := parentVM
+	vm.PowerState = wsapitest.PoweredOn
+	vm.IP = "192.168.1.10"
+	vmc, server := newTestClient(t, vm)
+	myvm, err := New(vmc).LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if myvm.IP != "" || server.CountRequests("GET", "vms/PARENT/nicips") != 0 {
+		t.Errorf("By default we don't want the network of the guest: %#v", myvm)
+	}
+	vms, err := New(vmc, WithGuestNetwork()).GetAllVMs()
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(vms) != 1 || vms[0].IP != "192.168.1.10" || len(vms[0].NICS) != 1 {
+		t.Errorf("We haven't loaded the network of the guest: %#v", vms)
+	}
+}
+
+func TestUpdateVM(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	server.SetPowerState("PARENT", wsapitest.PoweredOn)
+	vmm := New(vmc)
+	vm, err := vmm.LoadVM("PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = vmm.UpdateVM(vm, "parent", "The parent VM", 4, 4096, "")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.Processors != 4 || stored.Memory != 4096 || stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("The VM hasn't been updated: %#v", stored)
+	}
+}
+
+func TestRegisterVM(t *testing.T) {
+	vmc, server := newTestClient(t)
+	vm := &MyVm{Denomination: "registered", Path: "/vmware/registered/registered.vmx"}
+	err := New(vmc).RegisterVM(vm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.IdVM == "" || len(server.VMs()) != 1 {
+		t.Errorf("The VM hasn't been registered: %#v", vm)
+	}
+	err = New(vmc).RegisterVM(vm)
+	if !errors.Is(err, httpclient.ErrConflict) {
+		t.Errorf("Register twice should be a conflict: %#v", err)
+	}
+}
+
+func TestDeleteVM(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	server.SetPowerState("PARENT", wsapitest.PoweredOn)
+	err := New(vmc).DeleteVM(&MyVm{IdVM: "PARENT"})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if len(server.VMs()) != 0 {
+		t.Errorf("The VM hasn't been deleted: %#v", server.VMs())
+	}
+}
+
+func TestPowerOperations(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vmm := New(vmc)
+	vm := &MyVm{IdVM: "PARENT"}
+	server.SetPowerState("PARENT", wsapitest.PoweredOn)
+	steps := []struct {
+		name  string
+		do    func(*MyVm) error
+		want  string
+		calls int
+	}{
+		{"Suspend", vmm.Suspend, wsapitest.Suspended, 1},
+		{"Suspend again", vmm.Suspend, wsapitest.Suspended, 1},
+		{"Power on", func(vm *MyVm) error { return vmm.Power(vm, PowerOperationOn) }, wsapitest.PoweredOn, 2},
+		{"Pause", vmm.Pause, wsapitest.Paused, 3},
+		{"Unpause", vmm.Unpause, wsapitest.PoweredOn, 4},
+		{"Unpause again", vmm.Unpause, wsapitest.PoweredOn, 4},
+		{"Reset", vmm.Reset, wsapitest.PoweredOn, 5},
+		{"Reset again", vmm.Reset, wsapitest.PoweredOn, 6},
+		{"Shutdown", vmm.Shutdown, wsapitest.PoweredOff, 7},
+		{"Shutdown again", vmm.Shutdown, wsapitest.PoweredOff, 7},
+	}
+	for _, step := range steps {
+		err := step.do(vm)
+		if err != nil {
+			t.Fatalf("%s: %#v\n", step.name, err)
+		}
+		stored, _ := server.VM("PARENT")
+		if stored.PowerState != step.want || vm.PowerStatus != PowerStateConversor(step.want) {
+			t.Errorf("%s: the Power State is %#v, we want %#v", step.name, stored.PowerState, step.want)
+		}
+		if calls := server.CountRequests("PUT", "vms/PARENT/power"); calls != step.calls {
+			t.Errorf("%s: we have sent %d operations, we want %d", step.name, calls, step.calls)
+		}
+	}
+}
+
+func TestPowerOperationNotAllowed(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM)
+	err := New(vmc).Pause(&MyVm{IdVM: "PARENT"})
+	if !errors.Is(err, httpclient.ErrConflict) {
+		t.Errorf("We can't pause a VM that is off, the error should be ErrConflict: %#v", err)
+	}
+}
+
+func TestStopVM(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   StopPolicy
+		state    string
+		polls    int
+		wantErr  bool
+		wantOff  int
+		shutdown int
+	}{
+		{"Immediate shutdown", StopPolicy{}, wsapitest.PoweredOn, 0, false, 0, 1},
+		{"Slow guest", StopPolicy{}, wsapitest.PoweredOn, 3, false, 0, 1},
+		{"Guest ignore the shutdown", StopPolicy{}, wsapitest.PoweredOn, -1, false, 1, 1},
+		{"Graceful without fallback", StopPolicy{Mode: StopGraceful}, wsapitest.PoweredOn, -1, true, 0, 1},
+		{"Hard", StopPolicy{Mode: StopHard}, wsapitest.PoweredOn, 0, false, 1, 0},
+		{"Suspended", StopPolicy{}, wsapitest.Suspended, 0, true, 0, 0},
+		{"Suspended discarding the state", StopPolicy{DiscardState: true}, wsapitest.Suspended, 0, false, 1, 0},
+		{"Paused", StopPolicy{Mode: StopHard}, wsapitest.Paused, 0, true, 0, 0},
+		{"Paused discarding the state", StopPolicy{Mode: StopGraceful, DiscardState: true}, wsapitest.Paused, 0, false, 1, 0},
+		{"Already off", StopPolicy{}, wsapitest.PoweredOff, 0, false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := parentVM
+			vm.PowerState = tt.state
+			vm.ShutdownPolls = tt.polls
+			vmc, server := newTestClient(t, vm)
+			tt.policy.Timeout = 200 * time.Millisecond
+			tt.policy.PollInterval = 10 * time.Millisecond
+			vmm := New(vmc, WithStopPolicy(tt.policy))
+			myvm := &MyVm{IdVM: "PARENT"}
+			err := vmm.StopVM(myvm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StopVM() error = %#v, wantErr %v", err, tt.wantErr)
+			}
+			stored, _ := server.VM("PARENT")
+			if !tt.wantErr && (stored.PowerState != wsapitest.PoweredOff || myvm.PowerStatus != "off") {
+				t.Errorf("The VM isn't off: %#v %#v", stored, myvm)
+			}
+			if tt.wantErr && tt.state != wsapitest.PoweredOn && (!errors.Is(err, ErrInvalidState) || stored.PowerState != tt.state) {
+				t.Errorf("We should keep the state of the VM with ErrInvalidState: %#v %#v", stored, err)
+			}
+			var off, shutdown int
+			for _, request := range server.Requests() {
+				if request.Method == "PUT" && request.Body == "off" {
+					off++
+				}
+				if request.Method == "PUT" && request.Body == "shutdown" {
+					shutdown++
+				}
+			}
+			if off != tt.wantOff || shutdown != tt.shutdown {
+				t.Errorf("We have sent %d off and %d shutdown, we want %d and %d", off, shutdown, tt.wantOff, tt.shutdown)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package wsapivm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
 	"github.com/elsudano/vmware-workstation-api-client/httpclient"
-	"github.com/rs/zerolog/log"
 )
 
 // CloneVM Auxiliary function that allow us to clone a VM in a new one
@@ -18,37 +20,39 @@ import (
 // vm: (*wsapivm.MyVm) pointer to the VM that we are handling.
 // err: (error) If we will have some error we can handle it here.
 func CloneVM(vmc *httpclient.HTTPClient, pid string, n string) (*MyVm, error) {
+	return CloneVMContext(context.Background(), vmc, pid, n)
+}
+
+// CloneVMContext is the same as CloneVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func CloneVMContext(ctx context.Context, vmc *httpclient.HTTPClient, pid string, n string) (*MyVm, error) {
 	var vm *MyVm
 	var DataVM CreatePayload
 	DataVM.Name = n
 	DataVM.ParentId = pid
 	requestBody := new(bytes.Buffer)
 	err := json.NewEncoder(requestBody).Encode(DataVM)
-	log.Debug().Msgf("Request Body RAW: %#v", requestBody.String())
+	vmc.Log().Debug().Msgf("Request Body RAW: %#v", vmc.Redact(requestBody.String()))
 	if err != nil {
-		log.Error().Err(err).Msg("The request JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("clone VM %q from %q: encoding request: %w", n, pid, err)
 	}
-	response, err := vmc.ApiCall("vms", "POST", *requestBody)
+	response, err := vmc.ApiCallContext(ctx, "vms", "POST", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We can't made the API call.")
-		return nil, err
+		return nil, fmt.Errorf("clone VM %q from %q: %w", n, pid, err)
 	}
-	log.Debug().Msgf("Response RAW: %#v", response)
+	vmc.Log().Debug().Msgf("Response RAW: %#v", response)
 	responseBody := new(bytes.Buffer)
 	_, err = responseBody.ReadFrom(response)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("clone VM %q from %q: decoding response: %w", n, pid, err)
 	}
-	log.Debug().Msgf("Response Human Readable: %#v", responseBody.String())
+	vmc.Log().Debug().Msgf("Response Human Readable: %#v", vmc.Redact(responseBody.String()))
 	err = json.NewDecoder(responseBody).Decode(&vm)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("clone VM %q from %q: decoding response: %w", n, pid, err)
 	}
-	log.Debug().Msgf("VM is: %#v", vm)
-	log.Info().Msg("We have cloned the VM with the Path included.")
+	vmc.Log().Debug().Msgf("VM is: %#v", vm)
+	vmc.Log().Info().Msg("We have cloned the VM with the Path included.")
 	return vm, nil
 }
 
@@ -58,34 +62,30 @@ func CloneVM(vmc *httpclient.HTTPClient, pid string, n string) (*MyVm, error) {
 // i: (string) string with the ID yo VM
 // Outputs:
 // vm: (*wsapivm.MyVm) pointer to the VM that we are handling.
-// err: (error) If we will have some error we can handle it here.
+// err: (error) *VMNotFoundError if there isn't any VM with this ID.
 func GetVM(vmc *httpclient.HTTPClient, i string) (*MyVm, error) {
-	log.Info().Msgf("The VM Id value is: %#v", i)
-	var vms []MyVm
-	var vm MyVm
-	// If you want see the path of the VM it's necessary getting all VMs
-	// because the API of VmWare Workstation doesn't allow see this the another way
-	// --------- This Block read the path and the ID of the vm in order to load in the function --------- {{{
-	response, err := vmc.ApiCall("vms", "GET", bytes.Buffer{})
-	if err != nil {
-		log.Error().Err(err).Msg("We can't made the API call.")
-		return nil, err
+	return GetVMContext(context.Background(), vmc, i)
+}
+
+// GetVMContext is the same as GetVM but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetVMContext(ctx context.Context, vmc *httpclient.HTTPClient, i string) (*MyVm, error) {
+	vmc.Log().Info().Msgf("The VM Id value is: %#v", i)
+	if i == "" {
+		return nil, fmt.Errorf("get VM: %w", &VMNotFoundError{Key: "id", Value: i})
 	}
-	err = json.NewDecoder(response).Decode(&vms)
+	vms, err := listVMs(ctx, vmc)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return nil, err
+		return nil, fmt.Errorf("get VM %q: %w", i, err)
 	}
-	log.Debug().Msgf("List of VMs: %#v", vms)
-	for tempvm, value := range vms {
+	for pos, value := range vms {
 		if value.IdVM == i {
-			vm = vms[tempvm]
-			break
+			vmc.Log().Debug().Msgf("VM: %#v", vms[pos])
+			vmc.Log().Info().Msg("We have loaded the ID and Path values.")
+			return &vms[pos], nil
 		}
 	}
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have loaded the ID and Path values.")
-	return &vm, nil
+	return nil, fmt.Errorf("get VM %q: %w", i, &VMNotFoundError{Key: "id", Value: i})
 }
 
 // GetVMbyName Auxiliary function to get the data of the VM and don't repeat code
@@ -94,45 +94,106 @@ func GetVM(vmc *httpclient.HTTPClient, i string) (*MyVm, error) {
 // n: (string) The name of the VM that we want to get.
 // Outputs:
 // vm: (*wsapivm.MyVm) pointer to the VM that we are handling.
-// err: (error) If we will have some error we can handle it here.
+// err: (error) *VMNotFoundError if there isn't any VM with this name and
+// *AmbiguousVMError if there are more than one.
 func GetVMbyName(vmc *httpclient.HTTPClient, n string) (*MyVm, error) {
-	log.Info().Msgf("The VM name value is: %#v", n)
-	var vms []MyVm
-	var vm MyVm
+	return GetVMbyNameContext(context.Background(), vmc, n)
+}
+
+// GetVMbyNameContext is the same as GetVMbyName but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetVMbyNameContext(ctx context.Context, vmc *httpclient.HTTPClient, n string) (*MyVm, error) {
+	vmc.Log().Info().Msgf("The VM name value is: %#v", n)
+	var candidates []MyVm
 	var param ParamPayload
-	// If you want see the path of the VM it's necessary getting all VMs
-	// because the API of VmWare Workstation doesn't allow see this the another way
-	// --------- This Block read the path and the ID of the vm in order to load in the function --------- {{{
-	response, err := vmc.ApiCall("vms", "GET", bytes.Buffer{})
+	vms, err := listVMs(ctx, vmc)
 	if err != nil {
-		log.Error().Err(err).Msg("We can't made the API call.")
-		return nil, err
+		return nil, fmt.Errorf("get VM by name %q: %w", n, err)
 	}
-	err = json.NewDecoder(response).Decode(&vms)
-	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return nil, err
-	}
-	log.Debug().Msgf("List of VMs: %#v", vms)
-	for tempvm, value := range vms {
-		response, err = vmc.ApiCall("vms/"+value.IdVM+"/params/displayName", "GET", bytes.Buffer{})
+	// We have to read the name of all the VMs, in this way we know if there are two with the same name
+	for _, value := range vms {
+		response, err := vmc.ApiCallContext(ctx, "vms/"+value.IdVM+"/params/displayName", "GET", bytes.Buffer{})
 		if err != nil {
-			log.Error().Err(err).Msg("We couldn't complete the API call.")
-			return nil, err
+			return nil, fmt.Errorf("get VM by name %q: %w", n, err)
 		}
 		err = json.NewDecoder(response).Decode(&param)
 		if err != nil {
-			log.Error().Err(err).Msg("The response JSON is malformed.")
-			return nil, err
+			return nil, fmt.Errorf("get VM by name %q: decoding response: %w", n, err)
 		}
 		if param.Value == n {
-			vm = vms[tempvm]
-			break
+			candidates = append(candidates, value)
+		}
+	}
+	vm, err := uniqueVM(candidates, "name", n)
+	if err != nil {
+		return nil, fmt.Errorf("get VM by name %q: %w", n, err)
+	}
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have loaded the ID and Path values.")
+	return vm, nil
+}
+
+// GetVMbyPath Auxiliary function to get the data of the VM with the path of its .vmx file
+// Input:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// p: (string) The path of the .vmx file of the VM, like the API give it to us.
+// Outputs:
+// vm: (*wsapivm.MyVm) pointer to the VM that we are handling.
+// err: (error) *VMNotFoundError if there isn't any VM with this path.
+func GetVMbyPath(vmc *httpclient.HTTPClient, p string) (*MyVm, error) {
+	return GetVMbyPathContext(context.Background(), vmc, p)
+}
+
+// GetVMbyPathContext is the same as GetVMbyPath but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetVMbyPathContext(ctx context.Context, vmc *httpclient.HTTPClient, p string) (*MyVm, error) {
+	vmc.Log().Info().Msgf("The VM path value is: %#v", p)
+	var candidates []MyVm
+	vms, err := listVMs(ctx, vmc)
+	if err != nil {
+		return nil, fmt.Errorf("get VM by path %q: %w", p, err)
+	}
+	for _, value := range vms {
+		if value.Path == p {
+			candidates = append(candidates, value)
 		}
 	}
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have loaded the ID and Path values.")
-	return &vm, nil
+	vm, err := uniqueVM(candidates, "path", p)
+	if err != nil {
+		return nil, fmt.Errorf("get VM by path %q: %w", p, err)
+	}
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have loaded the ID and Path values.")
+	return vm, nil
+}
+
+// listVMs Auxiliary function to get the ID and the Path of all the VMs, if you want see
+// the path of the VM it's necessary getting all VMs because the API of VmWare Workstation
+// doesn't allow see this the another way.
+func listVMs(ctx context.Context, vmc *httpclient.HTTPClient) ([]MyVm, error) {
+	var vms []MyVm
+	response, err := vmc.ApiCallContext(ctx, "vms", "GET", bytes.Buffer{})
+	if err != nil {
+		return nil, err
+	}
+	err = json.NewDecoder(response).Decode(&vms)
+	if err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	vmc.Log().Debug().Msgf("List of VMs: %#v", vms)
+	return vms, nil
+}
+
+// uniqueVM Auxiliary function that return the only candidate, or the error if there are zero or more than one.
+func uniqueVM(candidates []MyVm, key string, value string) (*MyVm, error) {
+	switch len(candidates) {
+	case 0:
+		return nil, &VMNotFoundError{Key: key, Value: value}
+	case 1:
+		return &candidates[0], nil
+	default:
+		return nil, &AmbiguousVMError{Key: key, Value: value, Candidates: candidates}
+	}
 }
 
 // GetAllExtraParameters Auxiliary function to get all the Extra parameters
@@ -144,28 +205,24 @@ func GetVMbyName(vmc *httpclient.HTTPClient, n string) (*MyVm, error) {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func GetAllExtraParameters(vmc *httpclient.HTTPClient, vm *MyVm) error {
-	err := GetBasicInfo(vmc, vm)
+	return GetAllExtraParametersContext(context.Background(), vmc, vm)
+}
+
+// GetAllExtraParametersContext is the same as GetAllExtraParameters but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetAllExtraParametersContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) error {
+	err := GetBasicInfoContext(ctx, vmc, vm)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't show the Process and Memory.")
-		return err
+		return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
 	}
-	err = GetDenominationDescription(vmc, vm)
+	err = GetDenominationDescriptionContext(ctx, vmc, vm)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't show the Denomination and Description.")
-		return err
+		return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
 	}
-	err = GetPowerStatus(vmc, vm)
+	err = GetPowerStatusContext(ctx, vmc, vm)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't show the Power Status.")
-		return err
+		return fmt.Errorf("get extra parameters of VM %q: %w", vm.IdVM, err)
 	}
-	// if vm.PowerStatus == "on" {
-	// 	err = wsapinet.GetInfoNics(vmc, vm.IdVM)
-	// 	if err != nil {
-	// 		log.Error().Err(err).Msg("We couldn't show the Network information.")
-	// 		return err
-	// 	}
-	// }
 	return nil
 }
 
@@ -176,18 +233,22 @@ func GetAllExtraParameters(vmc *httpclient.HTTPClient, vm *MyVm) error {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func GetBasicInfo(vmc *httpclient.HTTPClient, vm *MyVm) error {
-	response, err := vmc.ApiCall("vms/"+vm.IdVM, "GET", bytes.Buffer{})
+	return GetBasicInfoContext(context.Background(), vmc, vm)
+}
+
+// GetBasicInfoContext is the same as GetBasicInfo but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetBasicInfoContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) error {
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM, "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("get basic info of VM %q: %w", vm.IdVM, err)
 	}
 	err = json.NewDecoder(response).Decode(&vm)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("get basic info of VM %q: decoding response: %w", vm.IdVM, err)
 	}
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have loaded the Processor and Memory values.")
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have loaded the Processor and Memory values.")
 	return nil
 }
 
@@ -200,33 +261,35 @@ func GetBasicInfo(vmc *httpclient.HTTPClient, vm *MyVm) error {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func SetBasicInfo(vmc *httpclient.HTTPClient, vm *MyVm, p int32, m int32) error {
+	return SetBasicInfoContext(context.Background(), vmc, vm, p, m)
+}
+
+// SetBasicInfoContext is the same as SetBasicInfo but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func SetBasicInfoContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, p int32, m int32) error {
 	var settings SettingPayload
 	settings.Processors = p
 	settings.Memory = m
 	requestBody := new(bytes.Buffer)
 	err := json.NewEncoder(requestBody).Encode(settings)
 	if err != nil {
-		log.Error().Err(err).Msgf("Trying to encode this request: %#v", settings)
-		return err
+		return fmt.Errorf("set basic info of VM %q: encoding request: %w", vm.IdVM, err)
 	}
-	log.Debug().Msgf("Request Human Readable: %#v", requestBody.String())
-	response, err := vmc.ApiCall("vms/"+vm.IdVM, "PUT", *requestBody)
+	vmc.Log().Debug().Msgf("Request Human Readable: %#v", vmc.Redact(requestBody.String()))
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM, "PUT", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("set basic info of VM %q: %w", vm.IdVM, err)
 	}
-	log.Debug().Msgf("Response RAW: %#v", response)
+	vmc.Log().Debug().Msgf("Response RAW: %#v", response)
 	responseBody := new(bytes.Buffer)
 	_, err = responseBody.ReadFrom(response)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't read the data from the Request.")
-		return err
+		return fmt.Errorf("set basic info of VM %q: decoding response: %w", vm.IdVM, err)
 	}
-	log.Debug().Msgf("Response Human Readable: %#v", responseBody.String())
+	vmc.Log().Debug().Msgf("Response Human Readable: %#v", vmc.Redact(responseBody.String()))
 	err = json.NewDecoder(responseBody).Decode(&vm)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("set basic info of VM %q: decoding response: %w", vm.IdVM, err)
 	}
 	return nil
 }
@@ -239,31 +302,33 @@ func SetBasicInfo(vmc *httpclient.HTTPClient, vm *MyVm, p int32, m int32) error
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func GetDenominationDescription(vmc *httpclient.HTTPClient, vm *MyVm) error {
+	return GetDenominationDescriptionContext(context.Background(), vmc, vm)
+}
+
+// GetDenominationDescriptionContext is the same as GetDenominationDescription but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetDenominationDescriptionContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) error {
 	var param ParamPayload
-	response, err := vmc.ApiCall("vms/"+vm.IdVM+"/params/displayName", "GET", bytes.Buffer{})
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/params/displayName", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("get denomination and description of VM %q: %w", vm.IdVM, err)
 	}
 	err = json.NewDecoder(response).Decode(&param)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("get denomination and description of VM %q: decoding response: %w", vm.IdVM, err)
 	}
 	vm.Denomination = param.Value
-	response, err = vmc.ApiCall("vms/"+vm.IdVM+"/params/annotation", "GET", bytes.Buffer{})
+	response, err = vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/params/annotation", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("get denomination and description of VM %q: %w", vm.IdVM, err)
 	}
 	err = json.NewDecoder(response).Decode(&param)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("get denomination and description of VM %q: decoding response: %w", vm.IdVM, err)
 	}
 	vm.Description = param.Value
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have loaded the Denomination and Description values.")
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have loaded the Denomination and Description values.")
 	return nil
 }
 
@@ -274,23 +339,146 @@ func GetDenominationDescription(vmc *httpclient.HTTPClient, vm *MyVm) error {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func GetPowerStatus(vmc *httpclient.HTTPClient, vm *MyVm) error {
+	return GetPowerStatusContext(context.Background(), vmc, vm)
+}
+
+// GetPowerStatusContext is the same as GetPowerStatus but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetPowerStatusContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) error {
 	var power_state_payload PowerStatePayload
-	response, err := vmc.ApiCall("vms/"+vm.IdVM+"/power", "GET", bytes.Buffer{})
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/power", "GET", bytes.Buffer{})
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("get power status of VM %q: %w", vm.IdVM, err)
 	}
 	err = json.NewDecoder(response).Decode(&power_state_payload)
 	vm.PowerStatus = PowerStateConversor(power_state_payload.Value)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
+		return fmt.Errorf("get power status of VM %q: decoding response: %w", vm.IdVM, err)
+	}
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have loaded the Power State value.")
+	return nil
+}
+
+// GetIP Auxiliary function in charge to get the IP that the guest OS report, the API
+// only give it to us when the VM is on and the VMware Tools are running.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to know the IP.
+// Outputs:
+// (string) The IP of the guest.
+// err: (error) If we will have some error we can handle it here.
+func GetIP(vmc *httpclient.HTTPClient, vm *MyVm) (string, error) {
+	return GetIPContext(context.Background(), vmc, vm)
+}
+
+// GetIPContext is the same as GetIP but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetIPContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) (string, error) {
+	var ip_payload IPPayload
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/ip", "GET", bytes.Buffer{})
+	if err != nil {
+		return "", fmt.Errorf("get IP of VM %q: %w", vm.IdVM, err)
+	}
+	err = json.NewDecoder(response).Decode(&ip_payload)
+	if err != nil {
+		return "", fmt.Errorf("get IP of VM %q: decoding response: %w", vm.IdVM, err)
+	}
+	vmc.Log().Debug().Msgf("The IP of the VM %#v is: %#v", vm.IdVM, ip_payload.IP)
+	return ip_payload.IP, nil
+}
+
+// GetNICIPs Auxiliary function in charge to get the network information that the guest OS
+// report, like GetIP the API only give it to us when the VM is on and the VMware Tools are running.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to know the network information.
+// Outputs:
+// (*InfoNetwork) The network information of the guest.
+// err: (error) If we will have some error we can handle it here.
+func GetNICIPs(vmc *httpclient.HTTPClient, vm *MyVm) (*InfoNetwork, error) {
+	return GetNICIPsContext(context.Background(), vmc, vm)
+}
+
+// GetNICIPsContext is the same as GetNICIPs but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetNICIPsContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) (*InfoNetwork, error) {
+	info := new(InfoNetwork)
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/nicips", "GET", bytes.Buffer{})
+	if err != nil {
+		return nil, fmt.Errorf("get NIC IPs of VM %q: %w", vm.IdVM, err)
+	}
+	err = json.NewDecoder(response).Decode(info)
+	if err != nil {
+		return nil, fmt.Errorf("get NIC IPs of VM %q: decoding response: %w", vm.IdVM, err)
+	}
+	vmc.Log().Debug().Msgf("The network of the VM %#v is: %#v", vm.IdVM, info)
+	return info, nil
+}
+
+// GetGuestNetwork Auxiliary function in charge to fill the IP, NICS, DNS, Routes, WINS
+// and DHCP values of the VM with the information of the guest OS. If the VM isn't on or
+// the guest doesn't give us the information, we leave these values empty without error.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to fill, we need its PowerStatus.
+// Outputs:
+// err: (error) If we will have some error we can handle it here.
+func GetGuestNetwork(vmc *httpclient.HTTPClient, vm *MyVm) error {
+	return GetGuestNetworkContext(context.Background(), vmc, vm)
+}
+
+// GetGuestNetworkContext is the same as GetGuestNetwork but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetGuestNetworkContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) error {
+	vm.SetNetwork("", nil)
+	if PowerState(vm.PowerStatus) != PowerStateOn {
+		vmc.Log().Debug().Msgf("The VM %#v is %#v, it doesn't have network information.", vm.IdVM, vm.PowerStatus)
+		return nil
+	}
+	ip, err := GetIPContext(ctx, vmc, vm)
+	if guestNotReady(err) {
+		vmc.Log().Warn().Msgf("The guest of the VM %#v doesn't give us its IP: %s", vm.IdVM, err)
+	} else if err != nil {
+		return err
+	}
+	info, err := GetNICIPsContext(ctx, vmc, vm)
+	if guestNotReady(err) {
+		vmc.Log().Warn().Msgf("The guest of the VM %#v doesn't give us its network: %s", vm.IdVM, err)
+	} else if err != nil {
 		return err
 	}
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have loaded the Power State value.")
+	vm.SetNetwork(ip, info)
+	vmc.Log().Info().Msg("We have loaded the network information of the guest.")
 	return nil
 }
 
+// guestNotReady Auxiliary function that return true when the API has answered with an error
+// because the guest isn't ready, in this case we don't have to fail. When the VM doesn't
+// exist or we don't have access, we want the error.
+func guestNotReady(err error) bool {
+	var apiErr *httpclient.APIError
+	return errors.As(err, &apiErr) && !errors.Is(err, httpclient.ErrNotFound) && !errors.Is(err, httpclient.ErrUnauthorized)
+}
+
+// SetNetwork method put the network information of the guest in the VM, with a
+// nil info we only keep the IP and we clean the rest of the values.
+// Inputs:
+// ip: (string) The IP that the guest report.
+// info: (*InfoNetwork) The network information of the guest.
+func (vm *MyVm) SetNetwork(ip string, info *InfoNetwork) {
+	vm.IP = ip
+	if info == nil {
+		info = new(InfoNetwork)
+	}
+	vm.NICS = info.Nics
+	vm.DNS = info.Dns
+	vm.Routes = info.Routes
+	vm.WINS = info.Wins
+	vm.DHCPv4 = info.Dhcpv4
+	vm.DHCPv6 = info.Dhcpv6
+}
+
 // PowerSwitch method that permit you change the state of the instance, so you will change
 // from power-off to power-on the state of the instance.
 // Inputs:
@@ -300,22 +488,26 @@ func GetPowerStatus(vmc *httpclient.HTTPClient, vm *MyVm) error {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func PowerSwitch(vmc *httpclient.HTTPClient, vm *MyVm, s string) error {
+	return PowerSwitchContext(context.Background(), vmc, vm, s)
+}
+
+// PowerSwitchContext is the same as PowerSwitch but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func PowerSwitchContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, s string) error {
 	var power_state_payload PowerStatePayload
 	requestBody := bytes.NewBufferString(s)
-	log.Debug().Msgf("The state that we want is: %#v", s)
-	response, err := vmc.ApiCall("vms/"+vm.IdVM+"/power", "PUT", *requestBody)
+	vmc.Log().Debug().Msgf("The state that we want is: %#v", s)
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/power", "PUT", *requestBody)
 	if err != nil {
-		log.Error().Err(err).Msg("We couldn't complete the API call.")
-		return err
+		return fmt.Errorf("switch power of VM %q to %q: %w", vm.IdVM, s, err)
 	}
 	err = json.NewDecoder(response).Decode(&power_state_payload)
 	vm.PowerStatus = PowerStateConversor(power_state_payload.Value)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("switch power of VM %q to %q: decoding response: %w", vm.IdVM, s, err)
 	}
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msg("We have changed the Power State.")
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msg("We have changed the Power State.")
 	return nil
 }
 
@@ -327,20 +519,102 @@ func PowerSwitch(vmc *httpclient.HTTPClient, vm *MyVm, s string) error {
 // Outputs:
 // s: (string) The normalized string
 func PowerStateConversor(ops string) (s string) {
-	log.Debug().Msgf("Power State RAW: %#v", ops)
-	log.Info().Msg("We have converted the Power State.")
+	return string(ParsePowerState(ops))
+}
+
+// ParsePowerState is the same as PowerStateConversor but it give us a PowerState
+// Inputs:
+// ops: (string) The original Power State, the string that the API of VmWare Workstation give us
+// Outputs:
+// (PowerState) The normalized Power State, PowerStateInvalid if we don't know it
+func ParsePowerState(ops string) PowerState {
 	switch ops {
-	case "poweredOn":
-		return "on"
-	case "poweringOn":
-		return "on"
-	case "poweredOff":
-		return "off"
-	case "poweringOff":
-		return "off"
+	case "poweredOn", "poweringOn":
+		return PowerStateOn
+	case "poweredOff", "poweringOff":
+		return PowerStateOff
+	case "suspended", "suspending":
+		return PowerStateSuspended
+	case "paused":
+		return PowerStatePaused
+	default:
+		return PowerStateInvalid
+	}
+}
+
+// Target method return the Power State that the VM will have after the operation.
+func (op PowerOperation) Target() PowerState {
+	switch op {
+	case PowerOperationOn, PowerOperationUnpause, PowerOperationReset:
+		return PowerStateOn
+	case PowerOperationOff, PowerOperationShutdown:
+		return PowerStateOff
+	case PowerOperationSuspend:
+		return PowerStateSuspended
+	case PowerOperationPause:
+		return PowerStatePaused
 	default:
-		return "Invalid Power State"
+		return PowerStateInvalid
+	}
+}
+
+// PowerOperate Auxiliary function to change the Power State of the VM with an operation,
+// if the VM is already in the Power State that the operation wants we don't make the call,
+// except with reset, that always restart the VM.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to change.
+// op: (PowerOperation) The operation that we want to do.
+// Outputs:
+// err: (error) If we will have some error we can handle it here.
+func PowerOperate(vmc *httpclient.HTTPClient, vm *MyVm, op PowerOperation) error {
+	return PowerOperateContext(context.Background(), vmc, vm, op)
+}
+
+// PowerOperateContext is the same as PowerOperate but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func PowerOperateContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, op PowerOperation) error {
+	target := op.Target()
+	if target == PowerStateInvalid {
+		return fmt.Errorf("power operation %q of VM %q: the operation isn't valid", op, vm.IdVM)
+	}
+	err := GetPowerStatusContext(ctx, vmc, vm)
+	if err != nil {
+		return fmt.Errorf("power operation %q of VM %q: %w", op, vm.IdVM, err)
+	}
+	if op != PowerOperationReset && PowerState(vm.PowerStatus) == target {
+		vmc.Log().Info().Msgf("The VM %#v is already %#v, we don't need to %#v it.", vm.IdVM, vm.PowerStatus, op)
+		return nil
+	}
+	return PowerSwitchContext(ctx, vmc, vm, string(op))
+}
+
+// GetParameter Auxiliary function to read the value of a parameter of the .vmx file of the VM
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to read.
+// p: (string) String with the name of the param, like displayName
+// Outputs:
+// (string) The value of the param.
+// err: (error) If we will have some error we can handle it here.
+func GetParameter(vmc *httpclient.HTTPClient, vm *MyVm, p string) (string, error) {
+	return GetParameterContext(context.Background(), vmc, vm, p)
+}
+
+// GetParameterContext is the same as GetParameter but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetParameterContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, p string) (string, error) {
+	var param ParamPayload
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/params/"+p, "GET", bytes.Buffer{})
+	if err != nil {
+		return "", fmt.Errorf("get parameter %q of VM %q: %w", p, vm.IdVM, err)
+	}
+	err = json.NewDecoder(response).Decode(&param)
+	if err != nil {
+		return "", fmt.Errorf("get parameter %q of VM %q: decoding response: %w", p, vm.IdVM, err)
 	}
+	vmc.Log().Debug().Msgf("The parameter %#v of the VM %#v is: %#v", p, vm.IdVM, param.Value)
+	return param.Value, nil
 }
 
 // SetParameter With this function you can set the value of the parameter.
@@ -354,28 +628,147 @@ func PowerStateConversor(ops string) (s string) {
 // Outputs:
 // err: (error) If we will have some error we can handle it here.
 func SetParameter(vmc *httpclient.HTTPClient, vm *MyVm, p string, v string) error {
+	return SetParameterContext(context.Background(), vmc, vm, p, v)
+}
+
+// SetParameterContext is the same as SetParameter but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func SetParameterContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, p string, v string) error {
+	return putParameter(ctx, vmc, vm, "configparams", p, v)
+}
+
+// SetVMParameter With this function you can set the value of a parameter of the VM
+// with the params endpoint, it's the same as SetParameter but vmrest attend it in a
+// different way, some versions only change the .vmx file with one of them.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to change.
+// p: (string) String with the name or param to set,
+// v: (string) String with the value of param
+// Outputs:
+// err: (error) If we will have some error we can handle it here.
+func SetVMParameter(vmc *httpclient.HTTPClient, vm *MyVm, p string, v string) error {
+	return SetVMParameterContext(context.Background(), vmc, vm, p, v)
+}
+
+// SetVMParameterContext is the same as SetVMParameter but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func SetVMParameterContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, p string, v string) error {
+	return putParameter(ctx, vmc, vm, "params", p, v)
+}
+
+// putParameter send the parameter p with the value v at the endpoint e of the VM.
+func putParameter(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, e string, p string, v string) error {
 	var param ParamPayload
 	param.Name = p
 	param.Value = v
 	requestBody := new(bytes.Buffer)
 	err := json.NewEncoder(requestBody).Encode(param)
 	if err != nil {
-		log.Error().Err(err).Msgf("Trying to encode this request: %#v", param)
-		return err
+		return fmt.Errorf("set parameter %q of VM %q: encoding request: %w", p, vm.IdVM, err)
 	}
-	log.Debug().Msgf("Request Human Readable: %#v", requestBody.String())
-	response, err := vmc.ApiCall("/vms/"+vm.IdVM+"/configparams", "PUT", *requestBody)
+	vmc.Log().Debug().Msgf("Request Human Readable: %#v", vmc.Redact(requestBody.String()))
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/"+e, "PUT", *requestBody)
 	if err != nil {
-		return err
+		return fmt.Errorf("set parameter %q of VM %q: %w", p, vm.IdVM, err)
 	}
 	responseBody := new(bytes.Buffer)
 	_, err = responseBody.ReadFrom(response)
 	if err != nil {
-		log.Error().Err(err).Msg("The response JSON is malformed.")
-		return err
+		return fmt.Errorf("set parameter %q of VM %q: decoding response: %w", p, vm.IdVM, err)
+	}
+	vmc.Log().Debug().Msgf("Response Human Readable: %#v", vmc.Redact(responseBody.String()))
+	vmc.Log().Debug().Msgf("VM: %#v", vm)
+	vmc.Log().Info().Msgf("We have defined new value in parameter: %#v", p)
+	return nil
+}
+
+// GetNICs Auxiliary function to get the NICs of the VM, the same information that
+// wsapinet give us, but we need it here to create the VMs.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to know the NICs.
+// Outputs:
+// (*NICsPayload) The NICs of the VM.
+// err: (error) If we will have some error we can handle it here.
+func GetNICs(vmc *httpclient.HTTPClient, vm *MyVm) (*NICsPayload, error) {
+	return GetNICsContext(context.Background(), vmc, vm)
+}
+
+// GetNICsContext is the same as GetNICs but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func GetNICsContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm) (*NICsPayload, error) {
+	nics := new(NICsPayload)
+	response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/nic", "GET", bytes.Buffer{})
+	if err != nil {
+		return nil, fmt.Errorf("get NICs of VM %q: %w", vm.IdVM, err)
+	}
+	err = json.NewDecoder(response).Decode(nics)
+	if err != nil {
+		return nil, fmt.Errorf("get NICs of VM %q: decoding response: %w", vm.IdVM, err)
+	}
+	vmc.Log().Debug().Msgf("The NICs of the VM %#v are: %#v", vm.IdVM, nics)
+	return nics, nil
+}
+
+// SetNICs Auxiliary function to replace all the NICs of the VM with the NICs that we want,
+// the API doesn't allow to change the MAC address, so we delete the NICs and create them
+// again, with that the new NICs have new MAC addresses.
+// Inputs:
+// vmc: (*httpclient.HTTPClient) pointer at the client of the API server.
+// vm: (*wsapivm.MyVm) The VM that we want to change.
+// nics: ([]NICSpec) The NICs that we want, if it's empty we create again the NICs that the VM has.
+// Outputs:
+// err: (error) If we will have some error we can handle it here.
+func SetNICs(vmc *httpclient.HTTPClient, vm *MyVm, nics []NICSpec) error {
+	return SetNICsContext(context.Background(), vmc, vm, nics)
+}
+
+// SetNICsContext is the same as SetNICs but the API calls are bound to ctx,
+// so they can be cancelled or limited with a deadline.
+func SetNICsContext(ctx context.Context, vmc *httpclient.HTTPClient, vm *MyVm, nics []NICSpec) error {
+	current, err := GetNICsContext(ctx, vmc, vm)
+	if err != nil {
+		return fmt.Errorf("set NICs of VM %q: %w", vm.IdVM, err)
+	}
+	if len(nics) == 0 {
+		for _, nic := range current.NICS {
+			nics = append(nics, NICSpec{Type: nic.Type, Vmnet: nic.Vmnet})
+		}
+	}
+	for _, nic := range current.NICS {
+		response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/nic/"+fmt.Sprint(nic.Index), "DELETE", bytes.Buffer{})
+		if err != nil {
+			return fmt.Errorf("set NICs of VM %q: delete NIC %d: %w", vm.IdVM, nic.Index, err)
+		}
+		responseBody := new(bytes.Buffer)
+		_, err = responseBody.ReadFrom(response)
+		response.Close()
+		if err != nil {
+			return fmt.Errorf("set NICs of VM %q: delete NIC %d: decoding response: %w", vm.IdVM, nic.Index, err)
+		}
+		vmc.Log().Debug().Msgf("Response Human Readable: %#v", vmc.Redact(responseBody.String()))
+		vmc.Log().Debug().Msgf("We have deleted the NIC %#v of the VM %#v", nic.Index, vm.IdVM)
+	}
+	for _, nic := range nics {
+		requestBody := new(bytes.Buffer)
+		err = json.NewEncoder(requestBody).Encode(nic)
+		if err != nil {
+			return fmt.Errorf("set NICs of VM %q: encoding request: %w", vm.IdVM, err)
+		}
+		response, err := vmc.ApiCallContext(ctx, "vms/"+vm.IdVM+"/nic", "POST", *requestBody)
+		if err != nil {
+			return fmt.Errorf("set NICs of VM %q: create NIC %s: %w", vm.IdVM, nic.Type, err)
+		}
+		responseBody := new(bytes.Buffer)
+		_, err = responseBody.ReadFrom(response)
+		response.Close()
+		if err != nil {
+			return fmt.Errorf("set NICs of VM %q: create NIC %s: decoding response: %w", vm.IdVM, nic.Type, err)
+		}
+		vmc.Log().Debug().Msgf("Response Human Readable: %#v", vmc.Redact(responseBody.String()))
+		vmc.Log().Debug().Msgf("We have created the NIC %#v in the VM %#v", nic, vm.IdVM)
 	}
-	log.Debug().Msgf("Response Human Readable: %#v", responseBody.String())
-	log.Debug().Msgf("VM: %#v", vm)
-	log.Info().Msgf("We have defined new value in parameter: %#v", p)
+	vmc.Log().Info().Msgf("We have put %d NICs in the VM.", len(nics))
 	return nil
 }
@@ -1,37 +1,318 @@
 package wsapivm
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
 
-func TestCloneVM(t *testing.T) {
+	"github.com/elsudano/vmware-workstation-api-client/httpclient"
+	"github.com/elsudano/vmware-workstation-api-client/wsapitest"
+)
 
+// newTestClient Auxiliary function to start a fake vmrest server and a client connected to it.
+func newTestClient(t *testing.T, vms ...wsapitest.VM) (*httpclient.HTTPClient, *wsapitest.Server) {
+	t.Helper()
+	server := wsapitest.NewServer(vms...)
+	t.Cleanup(server.Close)
+	vmc, err := httpclient.NewClient(server.URL, server.User, server.Password, false, "NONE")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	return vmc, server
 }
-func TestGetVM(t *testing.T) {
 
+// parentVM is the VM that we use as parent in the tests.
+var parentVM = wsapitest.VM{
+	ID:          "PARENT",
+	DisplayName: "parent",
+	Annotation:  "The parent VM",
+	Processors:  2,
+	Memory:      2048,
+	NICs:        []wsapitest.NIC{{Index: 1, Type: "nat", Vmnet: "vmnet8", MacAddress: "00:50:56:00:00:01"}},
 }
-func TestGetVMbyName(t *testing.T) {
 
+func TestCloneVM(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vm, err := CloneVM(vmc, "PARENT", "clone")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	clone, ok := server.VM(vm.IdVM)
+	if !ok || clone.DisplayName != "clone" {
+		t.Errorf("The server doesn't have the clone: %#v", clone)
+	}
+	if vm.CPU.Processors != 2 || vm.Memory != 2048 {
+		t.Errorf("The clone hasn't the settings of the parent: %#v", vm)
+	}
+}
+func TestGetVM(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM)
+	vm, err := GetVM(vmc, "PARENT")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.IdVM != "PARENT" || vm.Path != "/vmware/parent/parent.vmx" {
+		t.Errorf("We haven't loaded the ID and the Path: %#v", vm)
+	}
+}
+func TestGetVMbyName(t *testing.T) {
+	vmc, _ := newTestClient(t, wsapitest.VM{ID: "OTHER", DisplayName: "other"}, parentVM)
+	vm, err := GetVMbyName(vmc, "parent")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.IdVM != "PARENT" {
+		t.Errorf("We haven't found the VM by name: %#v", vm)
+	}
+}
+func TestGetVMNotFound(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	for key, lookup := range map[string]func() (*MyVm, error){
+		"id":   func() (*MyVm, error) { return GetVM(vmc, "MISSING") },
+		"name": func() (*MyVm, error) { return GetVMbyName(vmc, "missing") },
+		"path": func() (*MyVm, error) { return GetVMbyPath(vmc, "/vmware/missing/missing.vmx") },
+	} {
+		vm, err := lookup()
+		var notFound *VMNotFoundError
+		if vm != nil || !errors.Is(err, httpclient.ErrNotFound) || !errors.As(err, &notFound) || notFound.Key != key {
+			t.Errorf("Look for a missing VM by %s should be a VMNotFoundError: %#v %#v", key, vm, err)
+		}
+	}
+	server.ResetRequests()
+	_, err := GetVM(vmc, "")
+	if !errors.Is(err, httpclient.ErrNotFound) || len(server.Requests()) != 0 {
+		t.Errorf("An empty ID should be not found without API calls: %#v", err)
+	}
+}
+func TestGetVMbyNameAmbiguous(t *testing.T) {
+	vmc, _ := newTestClient(t, parentVM, wsapitest.VM{ID: "TWIN", DisplayName: "parent", Path: "/vmware/twin/twin.vmx"})
+	_, err := GetVMbyName(vmc, "parent")
+	var ambiguous *AmbiguousVMError
+	if !errors.Is(err, ErrAmbiguousVM) || !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("Two VMs with the same name should be an AmbiguousVMError: %#v", err)
+	}
+	if !strings.Contains(err.Error(), "PARENT (/vmware/parent/parent.vmx)") || !strings.Contains(err.Error(), "TWIN (/vmware/twin/twin.vmx)") {
+		t.Errorf("The error should list all the candidates: %s", err)
+	}
+}
+func TestGetVMbyPath(t *testing.T) {
+	vmc, _ := newTestClient(t, wsapitest.VM{ID: "OTHER", DisplayName: "other"}, parentVM)
+	vm, err := GetVMbyPath(vmc, "/vmware/parent/parent.vmx")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.IdVM != "PARENT" {
+		t.Errorf("We haven't found the VM by path: %#v", vm)
+	}
 }
 func TestGetAllExtraParameters(t *testing.T) {
-
+	vmc, _ := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := GetAllExtraParameters(vmc, vm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.Denomination != "parent" || vm.Description != "The parent VM" || vm.CPU.Processors != 2 || vm.Memory != 2048 || vm.PowerStatus != "off" {
+		t.Errorf("We haven't loaded all the parameters: %#v", vm)
+	}
 }
 func TestGetBasicInfo(t *testing.T) {
-
+	vmc, server := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := GetBasicInfo(vmc, vm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.CPU.Processors != 2 || vm.Memory != 2048 {
+		t.Errorf("We haven't loaded the Processor and Memory values: %#v", vm)
+	}
+	err = GetBasicInfo(vmc, &MyVm{IdVM: "MISSING"})
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
+	server.On("GET", "vms/PARENT").Malformed()
+	err = GetBasicInfo(vmc, vm)
+	if err == nil || !strings.Contains(err.Error(), "decoding response") {
+		t.Errorf("The error should be about the decoding of the response: %#v", err)
+	}
 }
 func TestSetBasicInfo(t *testing.T) {
-
+	vmc, server := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := SetBasicInfo(vmc, vm, 4, 4096)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.Processors != 4 || stored.Memory != 4096 || vm.CPU.Processors != 4 || vm.Memory != 4096 {
+		t.Errorf("We haven't changed the Processor and Memory values: %#v %#v", stored, vm)
+	}
+	server.SetPowerState("PARENT", wsapitest.PoweredOn)
+	err = SetBasicInfo(vmc, vm, 1, 1024)
+	if !errors.Is(err, httpclient.ErrConflict) {
+		t.Errorf("Change a running VM should be a conflict: %#v", err)
+	}
 }
 func TestGetDenominationDescription(t *testing.T) {
-
+	vmc, _ := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := GetDenominationDescription(vmc, vm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.Denomination != "parent" || vm.Description != "The parent VM" {
+		t.Errorf("We haven't loaded the Denomination and Description values: %#v", vm)
+	}
 }
 func TestGetPowerStatus(t *testing.T) {
-
+	vmc, server := newTestClient(t, parentVM)
+	server.SetPowerState("PARENT", wsapitest.PoweredOn)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := GetPowerStatus(vmc, vm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if vm.PowerStatus != "on" {
+		t.Errorf("We haven't loaded the Power State value: %#v", vm)
+	}
 }
 func TestPowerSwitch(t *testing.T) {
-
+	vmc, server := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := PowerSwitch(vmc, vm, "on")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if vm.PowerStatus != "on" || stored.PowerState != wsapitest.PoweredOn {
+		t.Errorf("We haven't changed the Power State: %#v %#v", vm, stored)
+	}
+	server.On("PUT", "vms/*/power").Busy()
+	err = PowerSwitch(vmc, vm, "off")
+	if !errors.Is(err, httpclient.ErrVMBusy) {
+		t.Errorf("The error should be ErrVMBusy: %#v", err)
+	}
 }
 func TestPowerStateConversor(t *testing.T) {
-
+	for raw, want := range map[string]string{
+		"poweredOn":   "on",
+		"poweringOn":  "on",
+		"poweredOff":  "off",
+		"poweringOff": "off",
+		"suspended":   "suspended",
+		"paused":      "paused",
+		"unknown":     "Invalid Power State",
+	} {
+		if got := PowerStateConversor(raw); got != want {
+			t.Errorf("PowerStateConversor(%q) = %q, we want %q", raw, got, want)
+		}
+	}
+}
+func TestPowerOperate(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	vm := &MyVm{IdVM: "PARENT"}
+	err := PowerOperate(vmc, vm, PowerOperationOff)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if server.CountRequests("PUT", "vms/PARENT/power") != 0 {
+		t.Errorf("We have sent the operation but the VM was already off: %#v", server.Requests())
+	}
+	err = PowerOperate(vmc, vm, PowerOperation("explode"))
+	if err == nil {
+		t.Errorf("The operation isn't valid, we should have an error")
+	}
+	if PowerOperationShutdown.Target() != PowerStateOff || PowerOperationUnpause.Target() != PowerStateOn {
+		t.Errorf("The targets of the operations aren't right")
+	}
+}
+func TestGetGuestNetwork(t *testing.T) {
+	vm := parentVM
+	vm.PowerState = wsapitest.PoweredOn
+	vmc, server := newTestClient(t, vm)
+	myvm := &MyVm{IdVM: "PARENT", PowerStatus: "on"}
+	err := GetGuestNetwork(vmc, myvm)
+	if err != nil || myvm.IP != "" || myvm.NICS != nil {
+		t.Fatalf("The guest isn't ready, we want an empty network without error: %#v %#v", err, myvm)
+	}
+	server.SetIP("PARENT", "192.168.1.10")
+	err = GetGuestNetwork(vmc, myvm)
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	if myvm.IP != "192.168.1.10" || len(myvm.NICS) != 1 || myvm.NICS[0].Mac != "00:50:56:00:00:01" || myvm.NICS[0].Ip[0] != "192.168.1.10/24" {
+		t.Errorf("We haven't loaded the NICs of the guest: %#v", myvm)
+	}
+	if myvm.DNS.Hostname != "parent" || myvm.DNS.Servers[0] != "192.168.1.1" || len(myvm.Routes) != 1 || !myvm.DHCPv4.Enabled {
+		t.Errorf("We haven't loaded the DNS, the routes or DHCP of the guest: %#v", myvm)
+	}
+	server.ResetRequests()
+	myvm.PowerStatus = "off"
+	err = GetGuestNetwork(vmc, myvm)
+	if err != nil || myvm.IP != "" || myvm.Routes != nil || len(server.Requests()) != 0 {
+		t.Errorf("The VM is off, we want to clean the network without API calls: %#v %#v", err, myvm)
+	}
+	err = GetGuestNetwork(vmc, &MyVm{IdVM: "MISSING", PowerStatus: "on"})
+	if !errors.Is(err, httpclient.ErrNotFound) {
+		t.Errorf("The error should be ErrNotFound: %#v", err)
+	}
 }
 func TestSetParameter(t *testing.T) {
+	vmc, server := newTestClient(t, parentVM)
+	err := SetParameter(vmc, &MyVm{IdVM: "PARENT"}, "guestinfo.hostname", "parent.local")
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if stored.Params["guestinfo.hostname"] != "parent.local" {
+		t.Errorf("We haven't defined the parameter: %#v", stored.Params)
+	}
+}
+
+// trackedBody count the response bodies that are still open.
+type trackedBody struct {
+	io.ReadCloser
+	open *atomic.Int32
+	once sync.Once
+}
+
+// Close method to implement the io.Closer interface.
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { b.open.Add(-1) })
+	return b.ReadCloser.Close()
+}
 
+func TestSetNICs(t *testing.T) {
+	server := wsapitest.NewServer(parentVM)
+	t.Cleanup(server.Close)
+	var open atomic.Int32
+	tracker := func(next http.RoundTripper) http.RoundTripper {
+		return httpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			response, err := next.RoundTrip(req)
+			if err == nil && req.Method != "GET" {
+				open.Add(1)
+				response.Body = &trackedBody{ReadCloser: response.Body, open: &open}
+			}
+			return response, err
+		})
+	}
+	vmc, err := httpclient.NewClient(server.URL, server.User, server.Password, false, "NONE", httpclient.WithMiddleware(tracker))
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	err = SetNICs(vmc, &MyVm{IdVM: "PARENT"}, []NICSpec{{Type: NICBridged}, {Type: NICCustom, Vmnet: "vmnet2"}})
+	if err != nil {
+		t.Fatalf("%#v\n", err)
+	}
+	stored, _ := server.VM("PARENT")
+	if len(stored.NICs) != 2 || stored.NICs[0].Type != NICBridged || stored.NICs[1].Vmnet != "vmnet2" {
+		t.Errorf("The VM hasn't the NICs that we want: %#v", stored.NICs)
+	}
+	if open.Load() != 0 {
+		t.Errorf("We have left %d response bodies open", open.Load())
+	}
 }